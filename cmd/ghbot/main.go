@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/ducminhgd/gossip-bot/config"
@@ -16,6 +20,12 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Bound the whole run by one deadline, and stop cleanly on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, cfg.RunTimeout)
+	defer cancel()
+
 	// Create services
 	newsService := services.NewNewsService(cfg.Sources)
 	githubService := services.NewGithubService(
@@ -26,31 +36,51 @@ func main() {
 
 	// Fetch news from all sources
 	log.Println("Fetching news from all sources...")
-	newsMap, err := newsService.FetchAllNews()
+	digest, err := newsService.FetchAllNews(ctx)
 	if err != nil {
 		log.Fatalf("Failed to fetch news: %v", err)
 	}
+	log.Printf("Fetched %d sources in %s, failed: %v", len(digest.Sections), digest.Duration().Round(time.Millisecond), digest.FailedSections())
+
+	// Merge the news items shared by several sources
+	if merged := services.DeduplicateNews(digest); merged > 0 {
+		log.Printf("Merged %d duplicate news items", merged)
+	}
+	if cfg.ClusterThreshold > 0 {
+		if merged := services.ClusterNews(digest, cfg.ClusterThreshold); merged > 0 {
+			log.Printf("Merged %d news items with similar titles", merged)
+		}
+	}
+
+	// Leave out the news items already sent in earlier digests
+	if repeated := newsService.ApplyHistory(digest); repeated > 0 {
+		log.Printf("Found %d news items already sent", repeated)
+	}
 
 	// Generate issue content
 	log.Println("Generating issue content...")
-	issueContent, err := githubService.GenerateIssueContent(newsMap)
+	issueContent, err := githubService.GenerateIssueContent(digest)
 	if err != nil {
 		log.Fatalf("Failed to generate issue content: %v", err)
 	}
 
 	// Create issue
-	now := time.Now().UTC()
-	today := now.Format("2006-01-02")
+	today := digest.Date()
 	issueTitle := fmt.Sprintf("Daily News Digest - %s", today)
 
 	log.Printf("Creating GitHub issue: %s", issueTitle)
-	issue, err := githubService.CreateIssue(issueTitle, issueContent)
+	issue, err := githubService.CreateIssue(ctx, issueTitle, issueContent)
 	if err != nil {
 		log.Fatalf("Failed to create issue: %v", err)
 	}
 
 	log.Printf("Successfully created issue #%d: %s", issue.GetNumber(), issue.GetHTMLURL())
 
+	// Remember the published news items so that the next digests don't repeat them
+	if err := newsService.RecordHistory(digest); err != nil {
+		log.Printf("WARNING: failed to record the published news: %v", err)
+	}
+
 	// Send Telegram message
 	telegramCfg, err := config.LoadTelegramConfig()
 	if err != nil {
@@ -58,18 +88,7 @@ func main() {
 	}
 
 	telegramService := services.NewTelegramService(telegramCfg.TelegramBotToken)
-	for source, newsList := range newsMap {
-		if len(newsList) == 0 {
-			continue
-		}
-		telegramContent := fmt.Sprintf("__**[%s] %s**__\n\n", today, source)
-
-		// Add news items - only titles, no descriptions
-		for i, news := range newsList {
-			telegramContent += fmt.Sprintf("%d. [%s](%s)\n", i+1, news.Title, news.URL)
-		}
-
-		telegramContent += "\n"
-		_ = telegramService.SendMessage(telegramContent, telegramCfg.TelegramChatID, telegramCfg.TelegramThreadID, services.TELEGRAM_PARSE_MODE_MARKDOWNV2)
+	if err := telegramService.SendDigest(digest, telegramCfg.TelegramChatID, telegramCfg.TelegramThreadID); err != nil {
+		log.Printf("WARNING: failed to send Telegram messages: %v", err)
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ducminhgd/gossip-bot/internal/models"
 	"github.com/joho/godotenv"
@@ -23,6 +24,13 @@ type Config struct {
 
 	// Sources is a list of news sources
 	Sources []models.Source
+
+	// RunTimeout bounds a whole run, from fetching the news to publishing it
+	RunTimeout time.Duration
+
+	// ClusterThreshold is the title similarity, between 0 and 1, from which news items of different
+	// sources are merged as one story, 0 disables clustering
+	ClusterThreshold float64
 }
 
 type TelegramConfig struct {
@@ -36,11 +44,82 @@ type TelegramConfig struct {
 	TelegramThreadID int64
 }
 
+// FetchConfig holds the settings used when fetching news from the sources
+type FetchConfig struct {
+	// Concurrency is the maximum number of sources fetched at the same time
+	Concurrency int
+
+	// SourceTimeout is the deadline for fetching a single source
+	SourceTimeout time.Duration
+
+	// RetryAttempts is the maximum number of attempts per HTTP request, 0 means the client default
+	RetryAttempts int
+
+	// RetryBudget is the maximum number of HTTP retries in a run, 0 means the client default
+	RetryBudget int
+
+	// CacheDir is the directory of the on-disk HTTP cache, empty disables caching
+	CacheDir string
+}
+
+const (
+	// DefaultFetchConcurrency is the default number of sources fetched at the same time
+	DefaultFetchConcurrency = 4
+
+	// DefaultSourceTimeout is the default deadline for fetching a single source
+	DefaultSourceTimeout = 60 * time.Second
+
+	// DefaultRunTimeout is the default deadline for a whole run
+	DefaultRunTimeout = 10 * time.Minute
+
+	// DefaultHistoryWindow is the default period during which a published news item is not sent again
+	DefaultHistoryWindow = 72 * time.Hour
+
+	// HistoryModeDrop removes the news items published within the history window from the digest
+	HistoryModeDrop = "drop"
+
+	// HistoryModeMark keeps the news items published within the history window, marked as repeated
+	HistoryModeMark = "mark"
+
+	// HistoryBackendJSON keeps the history in a JSON file, it is the only backend for now
+	HistoryBackendJSON = "json"
+)
+
+// HistoryConfig holds the settings of the history of published news items
+type HistoryConfig struct {
+	// File is the path of the history file, empty disables the history
+	File string
+
+	// Backend is the store the history is kept in, HistoryBackendJSON
+	Backend string
+
+	// Window is how long a published news item is not sent again
+	Window time.Duration
+
+	// Mode is what happens to a news item published within the window, HistoryModeDrop or HistoryModeMark
+	Mode string
+}
+
+// GithubAPIConfig holds the credentials of the GitHub sources
+type GithubAPIConfig struct {
+	// Token authenticates the requests of the GitHub sources to raise their rate limits, it is optional
+	Token string
+}
+
 type RedditAppConfig struct {
 	AppID     string
 	AppSecret string
 }
 
+// BlueskyAppConfig holds the app password the Bluesky source logs in with
+type BlueskyAppConfig struct {
+	// Identifier is the handle or email of the account, e.g. gossipbot.bsky.social
+	Identifier string
+
+	// AppPassword is an app password created in the account settings, not the account password
+	AppPassword string
+}
+
 // LoadConfig loads the configuration from environment variables
 func LoadConfig() (*Config, error) {
 	// Load .env file if it exists
@@ -99,12 +178,32 @@ func LoadConfig() (*Config, error) {
 
 		sourceSubSource := os.Getenv(fmt.Sprintf("SOURCE_%s_SUBSOURCE", sourceName))
 
+		var sourceTimeout time.Duration
+		if sourceTimeoutStr := os.Getenv(fmt.Sprintf("SOURCE_%s_TIMEOUT", sourceName)); sourceTimeoutStr != "" {
+			sourceTimeout, err = time.ParseDuration(sourceTimeoutStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SOURCE_%s_TIMEOUT: %v", sourceName, err)
+			}
+		}
+
+		sourceFilter, err := loadPostFilter(sourceName)
+		if err != nil {
+			return nil, err
+		}
+
 		source := models.Source{
-			Name:      sourceName,
-			Type:      sourceType,
-			URL:       sourceURL,
-			Limit:     sourceLimit,
-			SubSource: sourceSubSource,
+			Name:       sourceName,
+			Title:      strings.TrimSpace(os.Getenv(fmt.Sprintf("SOURCE_%s_TITLE", sourceName))),
+			Type:       sourceType,
+			URL:        sourceURL,
+			Limit:      sourceLimit,
+			SubSource:  sourceSubSource,
+			Listing:    strings.ToLower(os.Getenv(fmt.Sprintf("SOURCE_%s_LISTING", sourceName))),
+			TimeWindow: strings.ToLower(os.Getenv(fmt.Sprintf("SOURCE_%s_TIME_WINDOW", sourceName))),
+			Query:      os.Getenv(fmt.Sprintf("SOURCE_%s_QUERY", sourceName)),
+			Tags:       splitList(os.Getenv(fmt.Sprintf("SOURCE_%s_TAGS", sourceName))),
+			Filter:     sourceFilter,
+			Timeout:    sourceTimeout,
 		}
 
 		sources = append(sources, source)
@@ -114,14 +213,83 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("no valid sources configured")
 	}
 
+	runTimeout := DefaultRunTimeout
+	if runTimeoutStr := os.Getenv("RUN_TIMEOUT"); runTimeoutStr != "" {
+		parsedRunTimeout, err := time.ParseDuration(runTimeoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RUN_TIMEOUT: %v", err)
+		}
+		runTimeout = parsedRunTimeout
+	}
+
+	var clusterThreshold float64
+	if clusterThresholdStr := os.Getenv("CLUSTER_THRESHOLD"); clusterThresholdStr != "" {
+		parsedThreshold, err := strconv.ParseFloat(clusterThresholdStr, 64)
+		if err != nil || parsedThreshold < 0 || parsedThreshold > 1 {
+			return nil, fmt.Errorf("invalid CLUSTER_THRESHOLD: %q must be a number between 0 and 1", clusterThresholdStr)
+		}
+		clusterThreshold = parsedThreshold
+	}
+
 	return &Config{
-		GithubToken: githubToken,
-		GithubOwner: githubOwner,
-		GithubRepo:  githubRepo,
-		Sources:     sources,
+		GithubToken:      githubToken,
+		GithubOwner:      githubOwner,
+		GithubRepo:       githubRepo,
+		Sources:          sources,
+		RunTimeout:       runTimeout,
+		ClusterThreshold: clusterThreshold,
 	}, nil
 }
 
+// loadPostFilter loads the post filter of a source from its SOURCE_{NAME}_* environment variables
+func loadPostFilter(sourceName string) (models.PostFilter, error) {
+	filter := models.PostFilter{
+		SelfPosts:      strings.ToLower(os.Getenv(fmt.Sprintf("SOURCE_%s_SELF_POSTS", sourceName))),
+		IncludeFlairs:  splitList(os.Getenv(fmt.Sprintf("SOURCE_%s_INCLUDE_FLAIRS", sourceName))),
+		ExcludeFlairs:  splitList(os.Getenv(fmt.Sprintf("SOURCE_%s_EXCLUDE_FLAIRS", sourceName))),
+		IncludeDomains: splitList(os.Getenv(fmt.Sprintf("SOURCE_%s_INCLUDE_DOMAINS", sourceName))),
+		ExcludeDomains: splitList(os.Getenv(fmt.Sprintf("SOURCE_%s_EXCLUDE_DOMAINS", sourceName))),
+		IncludeTags:    splitList(os.Getenv(fmt.Sprintf("SOURCE_%s_INCLUDE_TAGS", sourceName))),
+		ExcludeTags:    splitList(os.Getenv(fmt.Sprintf("SOURCE_%s_EXCLUDE_TAGS", sourceName))),
+	}
+
+	switch filter.SelfPosts {
+	case "", "include", "exclude", "only":
+	default:
+		return filter, fmt.Errorf("invalid SOURCE_%s_SELF_POSTS: %q must be include, exclude or only", sourceName, filter.SelfPosts)
+	}
+
+	flags := map[string]*bool{
+		"EXCLUDE_STICKIED": &filter.ExcludeStickied,
+		"EXCLUDE_NSFW":     &filter.ExcludeNSFW,
+		"EXCLUDE_SPOILERS": &filter.ExcludeSpoilers,
+	}
+	for name, flag := range flags {
+		value := os.Getenv(fmt.Sprintf("SOURCE_%s_%s", sourceName, name))
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid SOURCE_%s_%s: %v", sourceName, name, err)
+		}
+		*flag = parsed
+	}
+
+	return filter, nil
+}
+
+// splitList splits a comma-separated environment variable, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func LoadTelegramConfig() (*TelegramConfig, error) {
 	// Load .env file if it exists
 	_ = godotenv.Load()
@@ -179,3 +347,122 @@ func LoadRedditAppConfig() (*RedditAppConfig, error) {
 		AppSecret: redditAppSecret,
 	}, nil
 }
+
+// LoadBlueskyAppConfig loads the Bluesky app password from environment variables.
+// It returns an error when it is not configured, the Bluesky source then reads the public API.
+func LoadBlueskyAppConfig() (*BlueskyAppConfig, error) {
+	// Load .env file if it exists
+	_ = godotenv.Load()
+
+	identifier := os.Getenv("BLUESKY_IDENTIFIER")
+	if identifier == "" {
+		return nil, fmt.Errorf("BLUESKY_IDENTIFIER environment variable is required")
+	}
+
+	appPassword := os.Getenv("BLUESKY_APP_PASSWORD")
+	if appPassword == "" {
+		return nil, fmt.Errorf("BLUESKY_APP_PASSWORD environment variable is required")
+	}
+
+	return &BlueskyAppConfig{
+		Identifier:  identifier,
+		AppPassword: appPassword,
+	}, nil
+}
+
+// LoadGithubAPIConfig loads the credentials of the GitHub sources from environment variables.
+// GITHUB_TOKEN is shared with the issue creation and may be empty.
+func LoadGithubAPIConfig() *GithubAPIConfig {
+	// Load .env file if it exists
+	_ = godotenv.Load()
+
+	return &GithubAPIConfig{
+		Token: os.Getenv("GITHUB_TOKEN"),
+	}
+}
+
+// LoadFetchConfig loads the fetch settings from environment variables.
+// Unset variables fall back to DefaultFetchConcurrency and DefaultSourceTimeout.
+func LoadFetchConfig() (*FetchConfig, error) {
+	// Load .env file if it exists
+	_ = godotenv.Load()
+
+	fetchConfig := &FetchConfig{
+		Concurrency:   DefaultFetchConcurrency,
+		SourceTimeout: DefaultSourceTimeout,
+	}
+
+	if concurrencyStr := os.Getenv("FETCH_CONCURRENCY"); concurrencyStr != "" {
+		concurrency, err := strconv.Atoi(concurrencyStr)
+		if err != nil || concurrency < 1 {
+			return nil, fmt.Errorf("invalid FETCH_CONCURRENCY: %q must be a positive integer", concurrencyStr)
+		}
+		fetchConfig.Concurrency = concurrency
+	}
+
+	if attemptsStr := os.Getenv("FETCH_RETRY_ATTEMPTS"); attemptsStr != "" {
+		attempts, err := strconv.Atoi(attemptsStr)
+		if err != nil || attempts < 1 {
+			return nil, fmt.Errorf("invalid FETCH_RETRY_ATTEMPTS: %q must be a positive integer", attemptsStr)
+		}
+		fetchConfig.RetryAttempts = attempts
+	}
+
+	if budgetStr := os.Getenv("FETCH_RETRY_BUDGET"); budgetStr != "" {
+		budget, err := strconv.Atoi(budgetStr)
+		if err != nil || budget < 1 {
+			return nil, fmt.Errorf("invalid FETCH_RETRY_BUDGET: %q must be a positive integer", budgetStr)
+		}
+		fetchConfig.RetryBudget = budget
+	}
+
+	if timeoutStr := os.Getenv("FETCH_SOURCE_TIMEOUT"); timeoutStr != "" {
+		timeout, err := time.ParseDuration(timeoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FETCH_SOURCE_TIMEOUT: %v", err)
+		}
+		fetchConfig.SourceTimeout = timeout
+	}
+
+	fetchConfig.CacheDir = os.Getenv("HTTP_CACHE_DIR")
+
+	return fetchConfig, nil
+}
+
+// LoadHistoryConfig loads the history settings from environment variables.
+// Unset variables fall back to DefaultHistoryWindow, HistoryModeDrop and HistoryBackendJSON.
+func LoadHistoryConfig() (*HistoryConfig, error) {
+	// Load .env file if it exists
+	_ = godotenv.Load()
+
+	historyConfig := &HistoryConfig{
+		File:    os.Getenv("HISTORY_FILE"),
+		Backend: HistoryBackendJSON,
+		Window:  DefaultHistoryWindow,
+		Mode:    HistoryModeDrop,
+	}
+
+	if windowStr := os.Getenv("HISTORY_WINDOW"); windowStr != "" {
+		window, err := time.ParseDuration(windowStr)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid HISTORY_WINDOW: %q must be a positive Go duration", windowStr)
+		}
+		historyConfig.Window = window
+	}
+
+	if modeStr := strings.ToLower(os.Getenv("HISTORY_MODE")); modeStr != "" {
+		if modeStr != HistoryModeDrop && modeStr != HistoryModeMark {
+			return nil, fmt.Errorf("invalid HISTORY_MODE: %q must be %q or %q", modeStr, HistoryModeDrop, HistoryModeMark)
+		}
+		historyConfig.Mode = modeStr
+	}
+
+	if backendStr := strings.ToLower(os.Getenv("HISTORY_BACKEND")); backendStr != "" {
+		if backendStr != HistoryBackendJSON {
+			return nil, fmt.Errorf("invalid HISTORY_BACKEND: %q must be %q", backendStr, HistoryBackendJSON)
+		}
+		historyConfig.Backend = backendStr
+	}
+
+	return historyConfig, nil
+}
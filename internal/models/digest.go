@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// SectionStatus is the outcome of fetching the source of a digest section
+type SectionStatus string
+
+const (
+	// SectionOK is a source fetched with at least one news item
+	SectionOK SectionStatus = "ok"
+
+	// SectionEmpty is a source fetched without any news item
+	SectionEmpty SectionStatus = "empty"
+
+	// SectionFailed is a source that could not be fetched, it is skipped by the renderers
+	SectionFailed SectionStatus = "failed"
+)
+
+// Section is the news items of one source in a digest
+type Section struct {
+	// Name is the name of the source (e.g., "RedditGo")
+	Name string `json:"name"`
+
+	// Title is the display title of the section, the source name unless the source has a title
+	Title string `json:"title"`
+
+	// Items are the news items of the source, in the order the source ranked them
+	Items []News `json:"items"`
+
+	// Status is the outcome of fetching the source
+	Status SectionStatus `json:"status"`
+
+	// Error is the reason a failed source could not be fetched
+	Error string `json:"error,omitempty"`
+
+	// Duration is how long fetching the source took
+	Duration time.Duration `json:"duration"`
+}
+
+// Digest is the news of a run, with one section per source in the configured order
+type Digest struct {
+	// StartedAt is the time the run started fetching
+	StartedAt time.Time `json:"started_at"`
+
+	// FinishedAt is the time the last source was fetched
+	FinishedAt time.Time `json:"finished_at"`
+
+	// Sections are the sections of the digest, in the order of the configured sources
+	Sections []Section `json:"sections"`
+}
+
+// Date returns the day of the digest, e.g. "2024-08-20"
+func (d *Digest) Date() string {
+	return d.StartedAt.UTC().Format("2006-01-02")
+}
+
+// Duration returns how long fetching the digest took
+func (d *Digest) Duration() time.Duration {
+	return d.FinishedAt.Sub(d.StartedAt)
+}
+
+// Section returns the section of a source by name
+func (d *Digest) Section(name string) (Section, bool) {
+	for _, section := range d.Sections {
+		if section.Name == name {
+			return section, true
+		}
+	}
+	return Section{}, false
+}
+
+// FailedSections returns the names of the sources that could not be fetched
+func (d *Digest) FailedSections() []string {
+	var names []string
+	for _, section := range d.Sections {
+		if section.Status == SectionFailed {
+			names = append(names, section.Name)
+		}
+	}
+	return names
+}
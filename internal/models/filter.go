@@ -0,0 +1,35 @@
+package models
+
+// PostFilter holds the include/exclude rules applied to the posts of a source
+// The zero value keeps every post.
+type PostFilter struct {
+	// ExcludeStickied drops pinned posts such as weekly megathreads
+	ExcludeStickied bool `json:"exclude_stickied,omitempty"`
+
+	// ExcludeNSFW drops posts marked as not safe for work
+	ExcludeNSFW bool `json:"exclude_nsfw,omitempty"`
+
+	// ExcludeSpoilers drops posts marked as spoilers
+	ExcludeSpoilers bool `json:"exclude_spoilers,omitempty"`
+
+	// SelfPosts controls text posts: "" or "include" keeps them, "exclude" drops them, "only" keeps nothing else
+	SelfPosts string `json:"self_posts,omitempty"`
+
+	// IncludeFlairs keeps only the posts with one of these flairs (case-insensitive)
+	IncludeFlairs []string `json:"include_flairs,omitempty"`
+
+	// ExcludeFlairs drops the posts with one of these flairs (case-insensitive)
+	ExcludeFlairs []string `json:"exclude_flairs,omitempty"`
+
+	// IncludeDomains keeps only the posts linking to one of these domains or their subdomains
+	IncludeDomains []string `json:"include_domains,omitempty"`
+
+	// ExcludeDomains drops the posts linking to one of these domains or their subdomains
+	ExcludeDomains []string `json:"exclude_domains,omitempty"`
+
+	// IncludeTags keeps only the posts with at least one of these tags (case-insensitive)
+	IncludeTags []string `json:"include_tags,omitempty"`
+
+	// ExcludeTags drops the posts with any of these tags (case-insensitive)
+	ExcludeTags []string `json:"exclude_tags,omitempty"`
+}
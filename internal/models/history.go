@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// HistoryEntry records a news item that was published in a digest
+type HistoryEntry struct {
+	// ID is the ID of the news item (e.g., "hackernews:8863")
+	ID string `json:"id,omitempty"`
+
+	// URL is the canonical URL of the news item, so that the same link posted again under another ID is recognised
+	URL string `json:"url,omitempty"`
+
+	// Title is the title of the news item, kept to make the history readable
+	Title string `json:"title"`
+
+	// FirstSentAt is the time the news item was first published
+	FirstSentAt time.Time `json:"first_sent_at"`
+
+	// LastSentAt is the time the news item was last published
+	LastSentAt time.Time `json:"last_sent_at"`
+}
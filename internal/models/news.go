@@ -27,4 +27,62 @@ type News struct {
 	
 	// Comments is the number of comments on the news item
 	Comments int `json:"comments"`
+	
+	// ID identifies the news item within its source type (e.g., "hackernews:8863" or "reddit:t3_abc123"),
+	// it is stable across runs
+	ID string `json:"id"`
+	
+	// Author is the submitter or author of the news item
+	Author string `json:"author,omitempty"`
+	
+	// Tags are the tags, categories or flair of the news item
+	Tags []string `json:"tags,omitempty"`
+	
+	// Rank is the 1-based position of the news item in the listing read from the source, before any re-sorting
+	Rank int `json:"rank,omitempty"`
+	
+	// DiscussionURL is the page of the comments on the news item, e.g. the Hacker News item of a link
+	DiscussionURL string `json:"discussion_url,omitempty"`
+	
+	// ImageURL is a thumbnail or cover image of the news item
+	ImageURL string `json:"image_url,omitempty"`
+	
+	// Language is the language of the news item as given by the source (e.g., "en")
+	Language string `json:"language,omitempty"`
+	
+	// FetchedAt is the time the news item was fetched
+	FetchedAt time.Time `json:"fetched_at"`
+	
+	// Discussions are the discussions of the news item on every source it was found in, the first one
+	// being the item's own, it is only set when the item merges duplicates from several sources
+	Discussions []Discussion `json:"discussions,omitempty"`
+	
+	// PreviouslySentAt is the time the news item was last published in an earlier digest,
+	// it is only set when the history marks repeated items instead of dropping them
+	PreviouslySentAt *time.Time `json:"previously_sent_at,omitempty"`
+}
+
+// Discussion is the discussion of a news item on one source
+type Discussion struct {
+	// Section is the title of the digest section the news item was found in (e.g., "Reddit Golang")
+	Section string `json:"section"`
+	
+	// Source is the source of the discussion (e.g., "Reddit")
+	Source string `json:"source"`
+	
+	// URL is the page of the discussion, or the news item URL when the source has no discussion page
+	URL string `json:"url"`
+	
+	// Score is the score of the news item on the source
+	Score int `json:"score"`
+	
+	// Comments is the number of comments on the source
+	Comments int `json:"comments"`
+	
+	// ID is the ID of the news item on the source, so that the history records merged items too
+	ID string `json:"id,omitempty"`
+	
+	// ItemURL is the URL of the news item on the source, which differs from the kept item's URL
+	// when the items were merged by their titles
+	ItemURL string `json:"item_url,omitempty"`
 }
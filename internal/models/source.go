@@ -1,19 +1,49 @@
 package models
 
+import "time"
+
 // Source represents a news source
 type Source struct {
 	// Name is the name of the source
 	Name string `json:"name"`
-	
-	// Type is the type of the source (e.g., "hackernews", "reddit")
+
+	// Title is the display title of the source in the digest, the name is shown when it is empty
+	Title string `json:"title,omitempty"`
+
+	// Type is the type of the source (e.g., "hackernews", "hackernews_algolia", "reddit")
 	Type string `json:"type"`
-	
+
 	// URL is the base URL of the source
 	URL string `json:"url"`
-	
+
 	// Limit is the maximum number of news items to fetch
 	Limit int `json:"limit"`
-	
-	// SubSource is the sub-source for sources like Reddit (e.g., "r/golang")
+
+	// SubSource is the sub-source for sources like Reddit (e.g., "golang", or "golang+rust" for a multireddit)
 	SubSource string `json:"sub_source,omitempty"`
+
+	// Listing selects which list of the source is read
+	// (e.g., "hot", "top", "new", "rising" for Reddit, or "top", "best", "new", "ask", "show", "job" for Hacker News)
+	Listing string `json:"listing,omitempty"`
+
+	// TimeWindow is the period covered by time-ranked listings (e.g., "hour", "day", "week")
+	TimeWindow string `json:"time_window,omitempty"`
+
+	// Query is the search query of search-backed sources (e.g., "golang" for the Hacker News search)
+	Query string `json:"query,omitempty"`
+
+	// Tags restricts search-backed sources to items with all of these tags
+	// (e.g., "show_hn" for the Hacker News search, or "go" for Stack Exchange)
+	Tags []string `json:"tags,omitempty"`
+
+	// Filter holds the include/exclude rules applied to the posts of the source
+	Filter PostFilter `json:"filter"`
+
+	// Timeout is the deadline for fetching this source, overriding the global source timeout
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// Since is the start of the last run whose digest was published with this source, zero on the first run
+	// or without a history. It is set by the news service, so that sources such as GitHub releases read
+	// everything published since then rather than a fixed time window.
+	Since time.Time `json:"-"`
 }
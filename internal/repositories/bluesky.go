@@ -0,0 +1,485 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/config"
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/utils"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+const (
+	// blueskyPublicURL is the public AppView read without authentication when the source has no URL
+	blueskyPublicURL = "https://public.api.bsky.app"
+
+	// blueskyPDSURL is the entryway that creates sessions and serves authenticated requests
+	blueskyPDSURL = "https://bsky.social"
+
+	// blueskyMaxLimit is the largest page of posts the XRPC endpoints return,
+	// the whole page is read so that the posts can be ranked
+	blueskyMaxLimit = 100
+
+	// blueskyTokenExpiryMargin renews the access token slightly before it expires
+	blueskyTokenExpiryMargin = time.Minute
+
+	// blueskyDefaultTokenLifetime is used when the expiry of an access token cannot be read
+	blueskyDefaultTokenLifetime = 30 * time.Minute
+
+	// blueskyTitleLength is the maximum number of characters of a title taken from the post text
+	blueskyTitleLength = 100
+
+	// blueskyDescriptionLength is the maximum number of characters of a post description
+	blueskyDescriptionLength = 200
+)
+
+// blueskyListings are the supported ways to read posts: a feed generator, the posts of an author or a search
+var blueskyListings = []string{"feed", "author", "search"}
+
+// BlueskyRepository handles fetching posts from Bluesky through the XRPC endpoints of the AppView.
+// When an app password is configured, the session is shared by all sources and refreshed when it expires.
+type BlueskyRepository struct {
+	httpClient http.HTTPClient
+
+	// appConfig is nil when no app password is configured
+	appConfig *config.BlueskyAppConfig
+
+	sessionMu    sync.Mutex
+	accessToken  string
+	refreshToken string
+	tokenExpiry  time.Time
+}
+
+// blueskyFeedResponse is the response of the getFeed, getAuthorFeed and searchPosts methods,
+// the first two return feed items while the search returns posts
+type blueskyFeedResponse struct {
+	Feed []struct {
+		Post blueskyPost `json:"post"`
+
+		// Reason is set when the post is in the feed because it was reposted
+		Reason *struct {
+			Type string `json:"$type"`
+		} `json:"reason"`
+	} `json:"feed"`
+	Posts []blueskyPost `json:"posts"`
+}
+
+// blueskyPost is the view of a post
+type blueskyPost struct {
+	URI    string `json:"uri"`
+	Author struct {
+		DID         string `json:"did"`
+		Handle      string `json:"handle"`
+		DisplayName string `json:"displayName"`
+	} `json:"author"`
+	Record struct {
+		Text      string   `json:"text"`
+		CreatedAt string   `json:"createdAt"`
+		Langs     []string `json:"langs"`
+		Tags      []string `json:"tags"`
+	} `json:"record"`
+	Embed       *blueskyEmbed `json:"embed"`
+	ReplyCount  int           `json:"replyCount"`
+	RepostCount int           `json:"repostCount"`
+	LikeCount   int           `json:"likeCount"`
+}
+
+// blueskyEmbed is the view of an embed, a link card is either the embed itself
+// or the media of a quote post
+type blueskyEmbed struct {
+	External *blueskyExternal `json:"external"`
+	Media    *struct {
+		External *blueskyExternal `json:"external"`
+	} `json:"media"`
+}
+
+// blueskyExternal is a link card
+type blueskyExternal struct {
+	URI         string `json:"uri"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Thumb       string `json:"thumb"`
+}
+
+// blueskySession is the response of the createSession and refreshSession methods
+type blueskySession struct {
+	AccessJwt  string `json:"accessJwt"`
+	RefreshJwt string `json:"refreshJwt"`
+	Handle     string `json:"handle"`
+	DID        string `json:"did"`
+}
+
+func init() {
+	Register("bluesky", func(httpClient http.HTTPClient) Fetcher {
+		return newBlueskyRepository(httpClient)
+	})
+}
+
+// NewBlueskyRepository creates a new BlueskyRepository
+func NewBlueskyRepository() *BlueskyRepository {
+	return newBlueskyRepository(http.NewClient())
+}
+
+// newBlueskyRepository creates a BlueskyRepository with the given HTTP client.
+// If the app password is not configured, only the public AppView is read.
+func newBlueskyRepository(httpClient http.HTTPClient) *BlueskyRepository {
+	// The app config is nil when the app password is not configured
+	appConfig, _ := config.LoadBlueskyAppConfig()
+
+	return &BlueskyRepository{
+		httpClient: httpClient,
+		appConfig:  appConfig,
+	}
+}
+
+// Fetch fetches the most liked and reposted posts of the source published within the source time window.
+// The listing selects a feed generator, the posts of the author given in the sub-source, or a search of
+// the source query; it defaults to a search when a query is set, a feed when the sub-source is a feed,
+// and the author otherwise.
+func (r *BlueskyRepository) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	requestPath, listing, err := r.requestPath(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.appConfig == nil {
+		return r.fetchPublic(ctx, source, requestPath, listing)
+	}
+
+	accessToken, err := r.getBlueskySession(ctx)
+	if err != nil {
+		// Fall back to the public AppView if the login fails
+		fmt.Printf("WARNING: failed to get Bluesky session, falling back to unauthenticated request: %v\n", err)
+		return r.fetchPublic(ctx, source, requestPath, listing)
+	}
+
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+	}
+	newsList, err := r.fetchPosts(ctx, blueskyPDSURL+requestPath, headers, listing, source)
+	if err != nil {
+		// A rejected token is dropped so that the next source refreshes the session
+		if statusErr, ok := http.AsStatusError(err); ok && statusErr.IsAuthError() {
+			r.invalidateSession()
+		}
+
+		fmt.Printf("WARNING: authenticated Bluesky request failed, falling back to unauthenticated request: %v\n", err)
+		return r.fetchPublic(ctx, source, requestPath, listing)
+	}
+
+	return newsList, nil
+}
+
+// fetchPublic fetches the posts of a source from the public AppView without authentication
+func (r *BlueskyRepository) fetchPublic(ctx context.Context, source models.Source, requestPath, listing string) ([]models.News, error) {
+	baseURL := strings.TrimSuffix(source.URL, "/")
+	if baseURL == "" {
+		baseURL = blueskyPublicURL
+	}
+
+	return r.fetchPosts(ctx, baseURL+requestPath, nil, listing, source)
+}
+
+// fetchPosts fetches and converts the posts of an XRPC request
+func (r *BlueskyRepository) fetchPosts(ctx context.Context, requestURL string, headers map[string]string, listing string, source models.Source) ([]models.News, error) {
+	body, err := r.httpClient.GetWithHeadersContext(ctx, requestURL, headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Bluesky %s posts: %w", listing, err)
+	}
+
+	var response blueskyFeedResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse Bluesky response: %w", err)
+	}
+
+	// The time window was validated with the request
+	window, _ := parseTimeWindow(source.TimeWindow)
+
+	posts := response.Posts
+	for _, item := range response.Feed {
+		// Reposts are not news of the author
+		if listing == "author" && item.Reason != nil {
+			continue
+		}
+		posts = append(posts, item.Post)
+	}
+
+	fetchedAt := now()
+	var newsList []models.News
+	for i, post := range posts {
+		publishedAt, err := time.Parse(time.RFC3339, post.Record.CreatedAt)
+		if err != nil {
+			fmt.Printf("WARNING: failed to parse Bluesky post date %s: %v\n", post.Record.CreatedAt, err)
+			publishedAt = now() // Use current time as fallback
+		}
+		if window > 0 && publishedAt.Before(now().Add(-window)) {
+			continue
+		}
+
+		if news, ok := blueskyNews(post, publishedAt); ok {
+			news.Rank = i + 1
+			news.FetchedAt = fetchedAt
+			newsList = append(newsList, news)
+		}
+	}
+
+	// Sort by likes and reposts, posts with the same score keep the feed order
+	sort.SliceStable(newsList, func(i, j int) bool {
+		return newsList[i].Score > newsList[j].Score
+	})
+
+	if source.Limit > 0 && len(newsList) > source.Limit {
+		newsList = newsList[:source.Limit]
+	}
+
+	return newsList, nil
+}
+
+// requestPath returns the XRPC request of a source and the name of its listing,
+// e.g. /xrpc/app.bsky.feed.getAuthorFeed?actor=golang.bsky.social&filter=posts_no_replies&limit=100
+func (r *BlueskyRepository) requestPath(source models.Source) (string, string, error) {
+	window, err := parseTimeWindow(source.TimeWindow)
+	if err != nil {
+		return "", "", fmt.Errorf("unsupported Bluesky time window: %w", err)
+	}
+
+	subSource := strings.TrimSpace(source.SubSource)
+	searchQuery := strings.TrimSpace(source.Query)
+
+	listing := strings.ToLower(source.Listing)
+	if listing == "" {
+		switch {
+		case searchQuery != "":
+			listing = "search"
+		case strings.HasPrefix(subSource, "at://") || strings.Contains(subSource, "/feed/"):
+			listing = "feed"
+		default:
+			listing = "author"
+		}
+	}
+	if !slices.Contains(blueskyListings, listing) {
+		return "", "", fmt.Errorf("unsupported Bluesky listing %q, expected one of %v", listing, blueskyListings)
+	}
+
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(blueskyMaxLimit))
+
+	switch listing {
+	case "feed":
+		feedURI, err := blueskyFeedURI(subSource)
+		if err != nil {
+			return "", "", err
+		}
+		query.Set("feed", feedURI)
+		return "/xrpc/app.bsky.feed.getFeed?" + query.Encode(), listing, nil
+
+	case "author":
+		actor := strings.TrimPrefix(subSource, "@")
+		if actor == "" {
+			return "", "", fmt.Errorf("author is required for Bluesky author listing, e.g. golang.bsky.social")
+		}
+		query.Set("actor", actor)
+		query.Set("filter", "posts_no_replies")
+		return "/xrpc/app.bsky.feed.getAuthorFeed?" + query.Encode(), listing, nil
+
+	default:
+		if searchQuery == "" {
+			return "", "", fmt.Errorf("query is required for Bluesky search listing, e.g. golang")
+		}
+		query.Set("q", searchQuery)
+		query.Set("sort", "top")
+		for _, tag := range source.Tags {
+			query.Add("tag", strings.TrimPrefix(tag, "#"))
+		}
+
+		if window > 0 {
+			query.Set("since", now().Add(-window).UTC().Format(time.RFC3339))
+		}
+		return "/xrpc/app.bsky.feed.searchPosts?" + query.Encode(), listing, nil
+	}
+}
+
+// blueskyFeedURI returns the AT URI of a feed generator given as an AT URI or as the link shared from the app,
+// e.g. https://bsky.app/profile/did:plc:abc/feed/golang becomes at://did:plc:abc/app.bsky.feed.generator/golang
+func blueskyFeedURI(subSource string) (string, error) {
+	if strings.HasPrefix(subSource, "at://") {
+		return subSource, nil
+	}
+
+	parsed, err := url.Parse(subSource)
+	if err == nil {
+		parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+		if len(parts) == 4 && parts[0] == "profile" && parts[2] == "feed" && parts[1] != "" && parts[3] != "" {
+			return fmt.Sprintf("at://%s/app.bsky.feed.generator/%s", parts[1], parts[3]), nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid Bluesky feed %q, expected an at:// URI or a https://bsky.app/profile/.../feed/... link", subSource)
+}
+
+// blueskyNews converts a post into a news item, it reports false for a post without text or link.
+// A post sharing a link points to the linked page and is titled after it.
+func blueskyNews(post blueskyPost, publishedAt time.Time) (models.News, bool) {
+	text := strings.Join(strings.Fields(post.Record.Text), " ")
+
+	// The web link of a post is built from the record key ending its AT URI
+	author := firstNonEmpty(post.Author.Handle, post.Author.DID)
+	recordKey := post.URI[strings.LastIndex(post.URI, "/")+1:]
+	discussionURL := ""
+	if author != "" && recordKey != "" {
+		discussionURL = fmt.Sprintf("https://bsky.app/profile/%s/post/%s", author, recordKey)
+	}
+	postURL := discussionURL
+	imageURL := ""
+
+	title := utils.Truncate(text, blueskyTitleLength)
+	description := utils.Truncate(text, blueskyDescriptionLength)
+	if external := post.external(); external != nil && external.URI != "" {
+		postURL = external.URI
+		imageURL = external.Thumb
+		if externalTitle := strings.TrimSpace(external.Title); externalTitle != "" {
+			title = externalTitle
+		}
+		if description == "" {
+			description = utils.Truncate(strings.TrimSpace(external.Description), blueskyDescriptionLength)
+		}
+	}
+	if title == "" || postURL == "" {
+		return models.News{}, false
+	}
+
+	if description == "" {
+		description = fmt.Sprintf("Likes: %d, Reposts: %d", post.LikeCount, post.RepostCount)
+	}
+
+	// A post may declare several languages, the first one is the main language
+	language := ""
+	if len(post.Record.Langs) > 0 {
+		language = post.Record.Langs[0]
+	}
+
+	return models.News{
+		Title:         title,
+		URL:           postURL,
+		Description:   description,
+		Source:        "Bluesky",
+		SubSource:     "@" + author,
+		PublishedAt:   publishedAt,
+		Score:         post.LikeCount + post.RepostCount,
+		Comments:      post.ReplyCount,
+		ID:            "bluesky:" + post.URI,
+		Author:        author,
+		Tags:          post.Record.Tags,
+		DiscussionURL: discussionURL,
+		ImageURL:      imageURL,
+		Language:      language,
+	}, true
+}
+
+// external returns the link card of a post, if any
+func (p blueskyPost) external() *blueskyExternal {
+	if p.Embed == nil {
+		return nil
+	}
+	if p.Embed.External != nil {
+		return p.Embed.External
+	}
+	if p.Embed.Media != nil {
+		return p.Embed.Media.External
+	}
+	return nil
+}
+
+// getBlueskySession returns the access token of the app password session, reusing it until it expires.
+// An expired session is refreshed, and a new session is created when it cannot be refreshed.
+func (r *BlueskyRepository) getBlueskySession(ctx context.Context) (string, error) {
+	// Holding the lock while requesting makes concurrent sources share a single session request
+	r.sessionMu.Lock()
+	defer r.sessionMu.Unlock()
+
+	if r.accessToken != "" && now().Before(r.tokenExpiry) {
+		return r.accessToken, nil
+	}
+
+	if r.refreshToken != "" {
+		// refreshSession takes no input, it is authenticated by the refresh token
+		headers := map[string]string{
+			"Authorization": "Bearer " + r.refreshToken,
+		}
+		body, err := r.httpClient.PostFormContext(ctx, blueskyPDSURL+"/xrpc/com.atproto.server.refreshSession", nil, headers)
+		if err == nil {
+			if err = r.storeSession(body); err == nil {
+				return r.accessToken, nil
+			}
+		}
+		fmt.Printf("WARNING: failed to refresh Bluesky session, creating a new one: %v\n", err)
+		r.refreshToken = ""
+	}
+
+	payload := map[string]string{
+		"identifier": r.appConfig.Identifier,
+		"password":   r.appConfig.AppPassword,
+	}
+	body, err := r.httpClient.PostJSONContext(ctx, blueskyPDSURL+"/xrpc/com.atproto.server.createSession", payload, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Bluesky session: %w", err)
+	}
+	if err := r.storeSession(body); err != nil {
+		return "", err
+	}
+
+	return r.accessToken, nil
+}
+
+// storeSession caches the tokens of a session response until the access token expires
+func (r *BlueskyRepository) storeSession(body []byte) error {
+	var session blueskySession
+	if err := json.Unmarshal(body, &session); err != nil {
+		return fmt.Errorf("failed to parse Bluesky session response: %w", err)
+	}
+	if session.AccessJwt == "" {
+		return fmt.Errorf("no access token received from Bluesky API")
+	}
+
+	r.accessToken = session.AccessJwt
+	r.refreshToken = session.RefreshJwt
+	r.tokenExpiry = blueskyTokenExpiry(session.AccessJwt, now()).Add(-blueskyTokenExpiryMargin)
+
+	return nil
+}
+
+// blueskyTokenExpiry returns the expiry time of a JWT access token from its exp claim,
+// or the default lifetime from now when the claim cannot be read
+func blueskyTokenExpiry(token string, now time.Time) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) == 3 {
+		if payload, err := base64.RawURLEncoding.DecodeString(parts[1]); err == nil {
+			var claims struct {
+				Exp int64 `json:"exp"`
+			}
+			if json.Unmarshal(payload, &claims) == nil && claims.Exp > 0 {
+				return time.Unix(claims.Exp, 0)
+			}
+		}
+	}
+
+	return now.Add(blueskyDefaultTokenLifetime)
+}
+
+// invalidateSession drops the cached access token, the refresh token is kept to renew the session
+func (r *BlueskyRepository) invalidateSession() {
+	r.sessionMu.Lock()
+	defer r.sessionMu.Unlock()
+	r.accessToken = ""
+	r.tokenExpiry = time.Time{}
+}
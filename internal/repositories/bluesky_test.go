@@ -0,0 +1,270 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/config"
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+// mockBlueskyAuthorFeed is an author feed with a link card post, a text post, a repost of another
+// account and a post older than the day window
+const mockBlueskyAuthorFeed = `{
+	"feed": [
+		{
+			"post": {
+				"uri": "at://did:plc:go/app.bsky.feed.post/3kabc",
+				"author": {"did": "did:plc:go", "handle": "golang.bsky.social", "displayName": "Go"},
+				"record": {"$type": "app.bsky.feed.post", "text": "Go 1.23 is out, with range over func\n\nRead more:", "createdAt": "2024-08-20T09:00:00.000Z", "langs": ["en"]},
+				"embed": {
+					"$type": "app.bsky.embed.external#view",
+					"external": {"uri": "https://go.dev/blog/go1.23", "title": "Go 1.23 is released", "description": "The Go team is happy to announce Go 1.23", "thumb": "https://cdn.bsky.app/img/feed_thumbnail/plain/did:plc:go/bafkrei@jpeg"}
+				},
+				"replyCount": 4,
+				"repostCount": 20,
+				"likeCount": 100
+			}
+		},
+		{
+			"post": {
+				"uri": "at://did:plc:go/app.bsky.feed.post/3kdef",
+				"author": {"did": "did:plc:go", "handle": "golang.bsky.social", "displayName": "Go"},
+				"record": {"$type": "app.bsky.feed.post", "text": "GopherCon talks are now online", "createdAt": "2024-08-20T10:00:00.000Z"},
+				"replyCount": 1,
+				"repostCount": 2,
+				"likeCount": 3
+			}
+		},
+		{
+			"post": {
+				"uri": "at://did:plc:other/app.bsky.feed.post/3kghi",
+				"author": {"did": "did:plc:other", "handle": "someone.bsky.social"},
+				"record": {"$type": "app.bsky.feed.post", "text": "A reposted post", "createdAt": "2024-08-20T11:00:00.000Z"},
+				"replyCount": 0,
+				"repostCount": 500,
+				"likeCount": 500
+			},
+			"reason": {"$type": "app.bsky.feed.defs#reasonRepost"}
+		},
+		{
+			"post": {
+				"uri": "at://did:plc:go/app.bsky.feed.post/3kold",
+				"author": {"did": "did:plc:go", "handle": "golang.bsky.social"},
+				"record": {"$type": "app.bsky.feed.post", "text": "An old post", "createdAt": "2024-08-10T11:00:00.000Z"},
+				"replyCount": 0,
+				"repostCount": 50,
+				"likeCount": 50
+			}
+		}
+	]
+}`
+
+// blueskyTestNow is the time the recorded responses are read at
+var blueskyTestNow = time.Date(2024, 8, 20, 12, 0, 0, 0, time.UTC)
+
+// blueskyTestToken returns an access token expiring at the given time
+func blueskyTestToken(name string, exp time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"scope":"com.atproto.appPass","exp":%d}`, exp.Unix())))
+	return "header." + payload + "." + name
+}
+
+func TestBlueskyRepository_Fetch_Public(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		GetWithHeadersFunc: func(url string, headers map[string]string) ([]byte, error) {
+			if url != "https://public.api.bsky.app/xrpc/app.bsky.feed.getAuthorFeed?actor=golang.bsky.social&filter=posts_no_replies&limit=100" {
+				t.Fatalf("Unexpected URL: %s", url)
+			}
+			if len(headers) != 0 {
+				t.Errorf("Expected no headers for public requests, got %v", headers)
+			}
+			return []byte(mockBlueskyAuthorFeed), nil
+		},
+	}
+	setTestNow(t, blueskyTestNow)
+	repo := &BlueskyRepository{httpClient: mockClient}
+	source := models.Source{Name: "BlueskyGo", Type: "bluesky", SubSource: "@golang.bsky.social", Limit: 10}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The repost and the post older than a day are skipped, posts are sorted by likes and reposts
+	if len(news) != 2 {
+		t.Fatalf("Expected 2 news items, got %d", len(news))
+	}
+
+	expectedLink := models.News{
+		Title:         "Go 1.23 is released",
+		URL:           "https://go.dev/blog/go1.23",
+		Description:   "Go 1.23 is out, with range over func Read more:",
+		Source:        "Bluesky",
+		SubSource:     "@golang.bsky.social",
+		PublishedAt:   time.Date(2024, 8, 20, 9, 0, 0, 0, time.UTC),
+		Score:         120,
+		Comments:      4,
+		ID:            "bluesky:at://did:plc:go/app.bsky.feed.post/3kabc",
+		Author:        "golang.bsky.social",
+		Rank:          1,
+		DiscussionURL: "https://bsky.app/profile/golang.bsky.social/post/3kabc",
+		ImageURL:      "https://cdn.bsky.app/img/feed_thumbnail/plain/did:plc:go/bafkrei@jpeg",
+		Language:      "en",
+		FetchedAt:     blueskyTestNow,
+	}
+	if !reflect.DeepEqual(news[0], expectedLink) {
+		t.Errorf("Expected %+v, got %+v", expectedLink, news[0])
+	}
+
+	// A post without a link card links to itself
+	if news[1].Title != "GopherCon talks are now online" || news[1].URL != "https://bsky.app/profile/golang.bsky.social/post/3kdef" {
+		t.Errorf("Unexpected second item: %+v", news[1])
+	}
+}
+
+func TestBlueskyRepository_Fetch_Session(t *testing.T) {
+	now := blueskyTestNow
+	var sessionRequests []string
+
+	mockClient := &MockHTTPClient{
+		PostJSONFunc: func(url string, payload any, headers map[string]string) ([]byte, error) {
+			sessionRequests = append(sessionRequests, url)
+			expectedPayload := map[string]string{"identifier": "bot.bsky.social", "password": "app-password"}
+			if !reflect.DeepEqual(payload, expectedPayload) {
+				t.Errorf("Expected payload %v, got %v", expectedPayload, payload)
+			}
+			return []byte(fmt.Sprintf(`{"accessJwt": %q, "refreshJwt": "refresh-1", "handle": "bot.bsky.social"}`, blueskyTestToken("access-1", now.Add(2*time.Hour)))), nil
+		},
+		PostFormFunc: func(url string, data url.Values, headers map[string]string) ([]byte, error) {
+			sessionRequests = append(sessionRequests, url)
+			if headers["Authorization"] != "Bearer refresh-1" {
+				t.Errorf("Expected the refresh token, got '%s'", headers["Authorization"])
+			}
+			return []byte(fmt.Sprintf(`{"accessJwt": %q, "refreshJwt": "refresh-2"}`, blueskyTestToken("access-2", now.Add(2*time.Hour)))), nil
+		},
+		GetWithHeadersFunc: func(url string, headers map[string]string) ([]byte, error) {
+			if !strings.HasPrefix(url, blueskyPDSURL+"/xrpc/app.bsky.feed.searchPosts?") {
+				t.Fatalf("Unexpected URL: %s", url)
+			}
+			if !strings.HasPrefix(headers["Authorization"], "Bearer header.") {
+				t.Errorf("Expected an access token, got '%s'", headers["Authorization"])
+			}
+			return []byte(`{"posts": []}`), nil
+		},
+	}
+	setTestNow(t, now)
+	repo := &BlueskyRepository{
+		httpClient: mockClient,
+		appConfig:  &config.BlueskyAppConfig{Identifier: "bot.bsky.social", AppPassword: "app-password"},
+	}
+	source := models.Source{Name: "BlueskyGo", Type: "bluesky", Query: "golang", Limit: 10}
+
+	// The session is shared by the sources until it expires
+	for i := 0; i < 2; i++ {
+		if _, err := repo.Fetch(context.Background(), source); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if len(sessionRequests) != 1 {
+		t.Fatalf("Expected a single session request, got %v", sessionRequests)
+	}
+
+	// Once the access token is about to expire, the session is refreshed
+	now = now.Add(2*time.Hour - blueskyTokenExpiryMargin)
+	setTestNow(t, now)
+	if _, err := repo.Fetch(context.Background(), source); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expectedRequests := []string{
+		blueskyPDSURL + "/xrpc/com.atproto.server.createSession",
+		blueskyPDSURL + "/xrpc/com.atproto.server.refreshSession",
+	}
+	if !reflect.DeepEqual(sessionRequests, expectedRequests) {
+		t.Errorf("Expected requests %v, got %v", expectedRequests, sessionRequests)
+	}
+	if repo.refreshToken != "refresh-2" || !strings.HasSuffix(repo.accessToken, ".access-2") {
+		t.Errorf("Expected the refreshed tokens, got %s and %s", repo.accessToken, repo.refreshToken)
+	}
+}
+
+func TestBlueskyRepository_FallbackToPublic(t *testing.T) {
+	var requestedURLs []string
+
+	mockClient := &MockHTTPClient{
+		PostJSONFunc: func(url string, payload any, headers map[string]string) ([]byte, error) {
+			return []byte(`{"accessJwt": "not-a-jwt", "refreshJwt": "refresh"}`), nil
+		},
+		GetWithHeadersFunc: func(url string, headers map[string]string) ([]byte, error) {
+			requestedURLs = append(requestedURLs, url)
+			if strings.HasPrefix(url, blueskyPDSURL) {
+				return nil, &http.StatusError{StatusCode: 401, URL: url}
+			}
+			return []byte(mockBlueskyAuthorFeed), nil
+		},
+	}
+	setTestNow(t, blueskyTestNow)
+	repo := &BlueskyRepository{
+		httpClient: mockClient,
+		appConfig:  &config.BlueskyAppConfig{Identifier: "bot.bsky.social", AppPassword: "app-password"},
+	}
+	source := models.Source{Name: "BlueskyGo", Type: "bluesky", SubSource: "golang.bsky.social", Limit: 1}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(news) != 1 || len(requestedURLs) != 2 || !strings.HasPrefix(requestedURLs[1], blueskyPublicURL) {
+		t.Errorf("Expected the public AppView to be read after the rejected request, got %v", requestedURLs)
+	}
+
+	// The rejected token is not reused, the refresh token is kept
+	if repo.accessToken != "" || repo.refreshToken != "refresh" {
+		t.Errorf("Expected the access token to be dropped, got %s and %s", repo.accessToken, repo.refreshToken)
+	}
+}
+
+func TestBlueskyRequestPath(t *testing.T) {
+	setTestNow(t, blueskyTestNow)
+	repo := &BlueskyRepository{}
+
+	testCases := []struct {
+		source    models.Source
+		expected  string
+		expectErr bool
+	}{
+		{models.Source{SubSource: "golang.bsky.social"}, "/xrpc/app.bsky.feed.getAuthorFeed?actor=golang.bsky.social&filter=posts_no_replies&limit=100", false},
+		{models.Source{SubSource: "at://did:plc:abc/app.bsky.feed.generator/golang"}, "/xrpc/app.bsky.feed.getFeed?feed=at%3A%2F%2Fdid%3Aplc%3Aabc%2Fapp.bsky.feed.generator%2Fgolang&limit=100", false},
+		{models.Source{SubSource: "https://bsky.app/profile/did:plc:abc/feed/golang"}, "/xrpc/app.bsky.feed.getFeed?feed=at%3A%2F%2Fdid%3Aplc%3Aabc%2Fapp.bsky.feed.generator%2Fgolang&limit=100", false},
+		{models.Source{Query: "golang generics", Tags: []string{"#go"}, TimeWindow: "week"}, "/xrpc/app.bsky.feed.searchPosts?limit=100&q=golang+generics&since=2024-08-13T12%3A00%3A00Z&sort=top&tag=go", false},
+		{models.Source{Query: "golang", TimeWindow: "all"}, "/xrpc/app.bsky.feed.searchPosts?limit=100&q=golang&sort=top", false},
+		{models.Source{Listing: "feed", SubSource: "https://bsky.app/profile/golang.bsky.social"}, "", true},
+		{models.Source{Listing: "search"}, "", true},
+		{models.Source{Listing: "timeline"}, "", true},
+		{models.Source{}, "", true},
+		{models.Source{SubSource: "golang.bsky.social", TimeWindow: "fortnight"}, "", true},
+	}
+
+	for _, tc := range testCases {
+		requestPath, _, err := repo.requestPath(tc.source)
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("%+v: expected an error, got %s", tc.source, requestPath)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v: expected no error, got %v", tc.source, err)
+			continue
+		}
+		if requestPath != tc.expected {
+			t.Errorf("%+v: expected %s, got %s", tc.source, tc.expected, requestPath)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/utils"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+const (
+	// devToURL is the base URL of dev.to, used when the source has no URL
+	devToURL = "https://dev.to"
+
+	// devToMaxPerPage is the largest page the dev.to API returns
+	devToMaxPerPage = 1000
+
+	// devToDescriptionLength is the maximum number of characters of an article description
+	devToDescriptionLength = 100
+)
+
+// DevToRepository handles fetching the top articles of a dev.to tag
+type DevToRepository struct {
+	httpClient http.HTTPClient
+}
+
+// devToArticle is an article of the /api/articles endpoint
+type devToArticle struct {
+	ID                   int      `json:"id"`
+	Title                string   `json:"title"`
+	Description          string   `json:"description"`
+	URL                  string   `json:"url"`
+	CommentsCount        int      `json:"comments_count"`
+	PublicReactionsCount int      `json:"public_reactions_count"`
+	PublishedAt          string   `json:"published_at"`
+	TagList              []string `json:"tag_list"`
+	CoverImage           string   `json:"cover_image"`
+	User                 struct {
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+func init() {
+	Register("devto", func(httpClient http.HTTPClient) Fetcher {
+		return &DevToRepository{httpClient: httpClient}
+	})
+}
+
+// NewDevToRepository creates a new DevToRepository
+func NewDevToRepository() *DevToRepository {
+	return &DevToRepository{
+		httpClient: http.NewClient(),
+	}
+}
+
+// Fetch fetches the most reacted articles of the tag given by the source sub-source, such as "go",
+// published within the source time window
+func (r *DevToRepository) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	articlesURL, err := devToArticlesURL(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var articles []devToArticle
+	if err := r.httpClient.GetJSONContext(ctx, articlesURL, &articles); err != nil {
+		return nil, fmt.Errorf("failed to fetch dev.to articles: %w", err)
+	}
+
+	fetchedAt := time.Now()
+	var newsList []models.News
+	for i, article := range articles {
+		if strings.TrimSpace(article.Title) == "" {
+			continue
+		}
+
+		publishedAt, err := time.Parse(time.RFC3339, article.PublishedAt)
+		if err != nil {
+			fmt.Printf("WARNING: failed to parse dev.to article date %s: %v\n", article.PublishedAt, err)
+			publishedAt = time.Now() // Use current time as fallback
+		}
+
+		description := utils.Truncate(utils.HTMLToText(article.Description), devToDescriptionLength)
+		if description == "" {
+			description = fmt.Sprintf("Reactions: %d, Comments: %d", article.PublicReactionsCount, article.CommentsCount)
+		}
+
+		newsList = append(newsList, models.News{
+			Title:         strings.TrimSpace(article.Title),
+			URL:           article.URL,
+			Description:   description,
+			Source:        "dev.to",
+			SubSource:     strings.Join(article.TagList, ", "),
+			PublishedAt:   publishedAt,
+			Score:         article.PublicReactionsCount,
+			Comments:      article.CommentsCount,
+			ID:            fmt.Sprintf("devto:%d", article.ID),
+			Author:        article.User.Username,
+			Tags:          article.TagList,
+			Rank:          i + 1,
+			DiscussionURL: article.URL,
+			ImageURL:      article.CoverImage,
+			FetchedAt:     fetchedAt,
+		})
+	}
+
+	// Sort by reactions, articles with the same score keep the API order
+	sort.SliceStable(newsList, func(i, j int) bool {
+		return newsList[i].Score > newsList[j].Score
+	})
+
+	if source.Limit > 0 && len(newsList) > source.Limit {
+		newsList = newsList[:source.Limit]
+	}
+
+	return newsList, nil
+}
+
+// devToArticlesURL returns the articles request of a source, e.g. https://dev.to/api/articles?per_page=10&tag=go&top=1
+func devToArticlesURL(source models.Source) (string, error) {
+	tag := strings.ToLower(strings.TrimSpace(source.SubSource))
+	if tag == "" {
+		return "", fmt.Errorf("tag is required for dev.to source, e.g. go")
+	}
+
+	window, err := parseTimeWindow(source.TimeWindow)
+	if err != nil {
+		return "", fmt.Errorf("unsupported dev.to time window: %w", err)
+	}
+
+	baseURL := strings.TrimSuffix(source.URL, "/")
+	if baseURL == "" {
+		baseURL = devToURL
+	}
+
+	query := url.Values{}
+	query.Set("tag", tag)
+	query.Set("per_page", strconv.Itoa(min(max(source.Limit, 1), devToMaxPerPage)))
+
+	// top ranks the most popular articles of that many days, without it the API returns its usual feed
+	if window > 0 {
+		days := int(math.Ceil(window.Hours() / 24))
+		query.Set("top", strconv.Itoa(days))
+	}
+
+	return fmt.Sprintf("%s/api/articles?%s", baseURL, query.Encode()), nil
+}
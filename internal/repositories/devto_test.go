@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+)
+
+// mockDevToArticles lists two Go articles, one without a description, around an article with a blank title
+const mockDevToArticles = `[
+	{
+		"id": 101,
+		"title": "Understanding Go generics",
+		"description": "A practical tour of type parameters &amp; constraints.",
+		"url": "https://dev.to/gopher/understanding-go-generics-1a2b",
+		"comments_count": 4,
+		"public_reactions_count": 35,
+		"published_at": "2024-08-20T09:00:00Z",
+		"tag_list": ["go", "generics"]
+	},
+	{
+		"id": 102,
+		"title": "  ",
+		"description": "",
+		"url": "https://dev.to/empty",
+		"comments_count": 0,
+		"public_reactions_count": 100,
+		"published_at": "2024-08-20T10:00:00Z",
+		"tag_list": []
+	},
+	{
+		"id": 103,
+		"title": "Profiling a Go service",
+		"description": "",
+		"url": "https://dev.to/gopher/profiling-a-go-service-3c4d",
+		"comments_count": 9,
+		"public_reactions_count": 80,
+		"published_at": "2024-08-20T11:30:00Z",
+		"tag_list": ["go", "performance"]
+	}
+]`
+
+func TestDevToRepository_Fetch(t *testing.T) {
+	mockClient := newJSONMockClient(t, "https://dev.to/api/articles?per_page=10&tag=go&top=1", mockDevToArticles)
+	repo := &DevToRepository{httpClient: mockClient}
+	source := models.Source{Name: "DevToGo", Type: "devto", SubSource: "Go", Limit: 10}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(news) != 2 {
+		t.Fatalf("Expected 2 news items, got %d", len(news))
+	}
+
+	// Articles are sorted by reactions
+	top := news[0]
+	if top.Title != "Profiling a Go service" || top.Score != 80 || top.Comments != 9 {
+		t.Errorf("Unexpected first item: %+v", top)
+	}
+	if top.Description != "Reactions: 80, Comments: 9" {
+		t.Errorf("Expected the reactions description, got '%s'", top.Description)
+	}
+
+	second := news[1]
+	if second.URL != "https://dev.to/gopher/understanding-go-generics-1a2b" || second.Source != "dev.to" ||
+		second.SubSource != "go, generics" {
+		t.Errorf("Unexpected second item: %+v", second)
+	}
+	if second.Description != "A practical tour of type parameters & constraints." {
+		t.Errorf("Expected the unescaped description, got '%s'", second.Description)
+	}
+	if !second.PublishedAt.Equal(time.Date(2024, 8, 20, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected published time 2024-08-20 09:00 UTC, got %v", second.PublishedAt)
+	}
+}
+
+func TestDevToArticlesURL(t *testing.T) {
+	testCases := []struct {
+		source    models.Source
+		expected  string
+		expectErr bool
+	}{
+		{models.Source{SubSource: "go"}, "https://dev.to/api/articles?per_page=1&tag=go&top=1", false},
+		{models.Source{URL: "https://dev.to/", SubSource: "rust", Limit: 5, TimeWindow: "week"}, "https://dev.to/api/articles?per_page=5&tag=rust&top=7", false},
+		{models.Source{SubSource: "go", Limit: 20, TimeWindow: "36h"}, "https://dev.to/api/articles?per_page=20&tag=go&top=2", false},
+		{models.Source{SubSource: "go", TimeWindow: "all"}, "https://dev.to/api/articles?per_page=1&tag=go", false},
+		{models.Source{SubSource: "go", TimeWindow: "fortnight"}, "", true},
+		{models.Source{}, "", true},
+	}
+
+	for _, tc := range testCases {
+		articlesURL, err := devToArticlesURL(tc.source)
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("%+v: expected an error, got %s", tc.source, articlesURL)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v: expected no error, got %v", tc.source, err)
+			continue
+		}
+		if articlesURL != tc.expected {
+			t.Errorf("%+v: expected %s, got %s", tc.source, tc.expected, articlesURL)
+		}
+	}
+}
@@ -0,0 +1,516 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/utils"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+const (
+	// feedAccept asks servers for any of the supported feed formats
+	feedAccept = "application/rss+xml, application/atom+xml, application/feed+json, application/xml, text/xml, application/json, */*"
+
+	// feedUserAgent identifies the bot to feed servers
+	feedUserAgent = "GossipBot/1.0 RSS Reader (https://github.com/ducminhgd/gossip-bot)"
+
+	// feedDescriptionLength is the maximum number of characters of an article description
+	feedDescriptionLength = 200
+)
+
+// FeedRepository handles fetching articles from RSS 2.0, Atom 1.0 and JSON Feed 1.1 feeds.
+// The format is detected from the document itself.
+type FeedRepository struct {
+	httpClient http.HTTPClient
+}
+
+// feed is a parsed feed, whatever its format
+type feed struct {
+	Title    string
+	Language string
+	Items    []feedItem
+}
+
+// feedItem is an entry of a feed
+type feedItem struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	Content     string
+	Authors     []string
+	Categories  []string
+	Published   string
+	Enclosures  []feedEnclosure
+
+	// Comments is the page of the comments on the item
+	Comments string
+
+	// Image is the thumbnail or banner of the item
+	Image string
+}
+
+// feedEnclosure is a file attached to a feed item, such as a podcast episode or an image
+type feedEnclosure struct {
+	URL    string
+	Type   string
+	Length int64
+}
+
+// rssDocument is an RSS 2.0 document
+type rssDocument struct {
+	Channel struct {
+		Title    string    `xml:"title"`
+		Language string    `xml:"language"`
+		Items    []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+// rssItem is an item of an RSS 2.0 channel, including the content and Dublin Core extensions
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Author      string   `xml:"author"`
+	Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate"`
+	Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+	GUID        string   `xml:"guid"`
+	Comments    string   `xml:"comments"`
+	Thumbnail   struct {
+		URL string `xml:"url,attr"`
+	} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Enclosures []struct {
+		URL    string `xml:"url,attr"`
+		Type   string `xml:"type,attr"`
+		Length int64  `xml:"length,attr"`
+	} `xml:"enclosure"`
+}
+
+// atomFeed is an Atom 1.0 document
+type atomFeed struct {
+	Lang    string      `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Title   atomText    `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// atomEntry is an entry of an Atom feed
+type atomEntry struct {
+	ID        string   `xml:"id"`
+	Title     atomText `xml:"title"`
+	Summary   atomText `xml:"summary"`
+	Content   atomText `xml:"content"`
+	Published string   `xml:"published"`
+	Updated   string   `xml:"updated"`
+	Links     []struct {
+		Href   string `xml:"href,attr"`
+		Rel    string `xml:"rel,attr"`
+		Type   string `xml:"type,attr"`
+		Length int64  `xml:"length,attr"`
+	} `xml:"link"`
+	Authors []struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Categories []struct {
+		Term  string `xml:"term,attr"`
+		Label string `xml:"label,attr"`
+	} `xml:"category"`
+}
+
+// atomText is an Atom text construct, holding plain text, escaped HTML or inline XHTML
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// jsonFeed is a JSON Feed 1.1 document, also accepting the author field of version 1.0
+type jsonFeed struct {
+	Version  string `json:"version"`
+	Title    string `json:"title"`
+	Language string `json:"language"`
+	Items    []struct {
+		ID            string   `json:"id"`
+		URL           string   `json:"url"`
+		ExternalURL   string   `json:"external_url"`
+		Title         string   `json:"title"`
+		ContentHTML   string   `json:"content_html"`
+		ContentText   string   `json:"content_text"`
+		Summary       string   `json:"summary"`
+		DatePublished string   `json:"date_published"`
+		DateModified  string   `json:"date_modified"`
+		Tags          []string `json:"tags"`
+		Image         string   `json:"image"`
+		BannerImage   string   `json:"banner_image"`
+		Authors       []struct {
+			Name string `json:"name"`
+		} `json:"authors"`
+		Author *struct {
+			Name string `json:"name"`
+		} `json:"author"`
+		Attachments []struct {
+			URL         string `json:"url"`
+			MimeType    string `json:"mime_type"`
+			SizeInBytes int64  `json:"size_in_bytes"`
+		} `json:"attachments"`
+	} `json:"items"`
+}
+
+func init() {
+	Register("feed", func(httpClient http.HTTPClient) Fetcher {
+		return &FeedRepository{httpClient: httpClient}
+	})
+}
+
+// NewFeedRepository creates a new FeedRepository
+func NewFeedRepository() *FeedRepository {
+	return &FeedRepository{
+		httpClient: http.NewClient(),
+	}
+}
+
+// Fetch fetches the latest articles of the feed at the source URL
+func (r *FeedRepository) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	headers := map[string]string{
+		"Accept":     feedAccept,
+		"User-Agent": feedUserAgent,
+	}
+	body, err := r.httpClient.GetWithHeadersContext(ctx, source.URL, headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed %s: %w", source.Name, err)
+	}
+
+	parsed, err := parseFeed(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse feed %s: %w", source.Name, err)
+	}
+
+	// Articles are attributed to the feed title, such as "The Go Blog"
+	label := strings.TrimSpace(parsed.Title)
+	if label == "" {
+		label = source.Name
+	}
+
+	return feedNews(parsed, source, label)
+}
+
+// parseFeed parses an RSS 2.0, Atom 1.0 or JSON Feed document
+func parseFeed(body []byte) (*feed, error) {
+	body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("empty feed")
+	}
+
+	if trimmed[0] == '{' {
+		return parseJSONFeed(trimmed)
+	}
+
+	root, err := feedRootElement(trimmed)
+	if err != nil {
+		return nil, err
+	}
+
+	switch root {
+	case "rss":
+		return parseRSS(trimmed)
+	case "feed":
+		return parseAtom(trimmed)
+	default:
+		return nil, fmt.Errorf("unsupported feed format with root element <%s>", root)
+	}
+}
+
+// feedRootElement returns the name of the root element of an XML document
+func feedRootElement(body []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("no root element found")
+		}
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// parseRSS parses an RSS 2.0 document
+func parseRSS(body []byte) (*feed, error) {
+	var document rssDocument
+	if err := xml.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+
+	parsed := &feed{Title: document.Channel.Title, Language: strings.TrimSpace(document.Channel.Language)}
+	for _, item := range document.Channel.Items {
+		entry := feedItem{
+			ID:          strings.TrimSpace(item.GUID),
+			Title:       strings.TrimSpace(item.Title),
+			Link:        strings.TrimSpace(item.Link),
+			Description: item.Description,
+			Content:     item.Content,
+			Categories:  trimmedStrings(item.Categories),
+			Published:   firstNonEmpty(item.PubDate, item.Date),
+			Comments:    strings.TrimSpace(item.Comments),
+			Image:       strings.TrimSpace(item.Thumbnail.URL),
+		}
+		entry.Authors = trimmedStrings([]string{firstNonEmpty(item.Author, item.Creator)})
+		for _, enclosure := range item.Enclosures {
+			entry.Enclosures = append(entry.Enclosures, feedEnclosure{URL: enclosure.URL, Type: enclosure.Type, Length: enclosure.Length})
+		}
+		parsed.Items = append(parsed.Items, entry)
+	}
+
+	return parsed, nil
+}
+
+// parseAtom parses an Atom 1.0 document
+func parseAtom(body []byte) (*feed, error) {
+	var document atomFeed
+	if err := xml.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+
+	parsed := &feed{Title: document.Title.PlainText(), Language: document.Lang}
+	for _, entry := range document.Entries {
+		item := feedItem{
+			ID:          strings.TrimSpace(entry.ID),
+			Title:       entry.Title.PlainText(),
+			Description: entry.Summary.String(),
+			Content:     entry.Content.String(),
+			Published:   firstNonEmpty(entry.Published, entry.Updated),
+		}
+
+		for _, link := range entry.Links {
+			switch link.Rel {
+			case "", "alternate":
+				if item.Link == "" {
+					item.Link = strings.TrimSpace(link.Href)
+				}
+			case "enclosure":
+				item.Enclosures = append(item.Enclosures, feedEnclosure{URL: link.Href, Type: link.Type, Length: link.Length})
+			case "replies":
+				// Comment feeds are not a discussion page, only HTML replies are kept
+				if item.Comments == "" && (link.Type == "" || link.Type == "text/html") {
+					item.Comments = strings.TrimSpace(link.Href)
+				}
+			}
+		}
+		for _, author := range entry.Authors {
+			item.Authors = append(item.Authors, author.Name)
+		}
+		for _, category := range entry.Categories {
+			item.Categories = append(item.Categories, firstNonEmpty(category.Label, category.Term))
+		}
+		item.Authors = trimmedStrings(item.Authors)
+		item.Categories = trimmedStrings(item.Categories)
+
+		parsed.Items = append(parsed.Items, item)
+	}
+
+	return parsed, nil
+}
+
+// String returns the content of an Atom text construct, keeping the markup of inline XHTML
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+// PlainText returns the content of an Atom text construct as plain text
+func (t atomText) PlainText() string {
+	if t.Type == "html" || t.Type == "xhtml" {
+		return utils.HTMLToText(t.String())
+	}
+	return t.String()
+}
+
+// parseJSONFeed parses a JSON Feed document
+func parseJSONFeed(body []byte) (*feed, error) {
+	var document jsonFeed
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(document.Version, "https://jsonfeed.org/version/") {
+		return nil, fmt.Errorf("unsupported JSON document, expected a JSON Feed version, got %q", document.Version)
+	}
+
+	parsed := &feed{Title: document.Title, Language: document.Language}
+	for _, item := range document.Items {
+		entry := feedItem{
+			ID:          item.ID,
+			Title:       strings.TrimSpace(item.Title),
+			Link:        strings.TrimSpace(firstNonEmpty(item.URL, item.ExternalURL)),
+			Description: item.Summary,
+			Content:     firstNonEmpty(item.ContentHTML, item.ContentText),
+			Categories:  trimmedStrings(item.Tags),
+			Published:   firstNonEmpty(item.DatePublished, item.DateModified),
+			Image:       firstNonEmpty(item.Image, item.BannerImage),
+		}
+		for _, author := range item.Authors {
+			entry.Authors = append(entry.Authors, author.Name)
+		}
+		if item.Author != nil {
+			entry.Authors = append(entry.Authors, item.Author.Name)
+		}
+		entry.Authors = trimmedStrings(entry.Authors)
+		for _, attachment := range item.Attachments {
+			entry.Enclosures = append(entry.Enclosures, feedEnclosure{URL: attachment.URL, Type: attachment.MimeType, Length: attachment.SizeInBytes})
+		}
+		parsed.Items = append(parsed.Items, entry)
+	}
+
+	return parsed, nil
+}
+
+// feedNews converts the items of a feed into news items attributed to label,
+// keeping the source limit of most recent items
+func feedNews(parsed *feed, source models.Source, label string) ([]models.News, error) {
+	var newsList []models.News
+	var skippedArticles []string
+
+	fetchedAt := time.Now()
+	for i, item := range parsed.Items {
+		// Skip articles with empty titles
+		if item.Title == "" {
+			fmt.Printf("WARNING: skipping %s article with empty title\n", label)
+			skippedArticles = append(skippedArticles, "unknown article")
+			continue
+		}
+
+		// Parse publication date
+		publishedAt, err := parseFeedDate(item.Published)
+		if err != nil {
+			fmt.Printf("WARNING: failed to parse %s article date %s: %v\n", label, item.Published, err)
+			publishedAt = time.Now() // Use current time as fallback
+		}
+
+		// Podcast-like items may only link to their enclosure
+		link := item.Link
+		if link == "" && len(item.Enclosures) > 0 {
+			link = item.Enclosures[0].URL
+		}
+		if link == "" && (strings.HasPrefix(item.ID, "https://") || strings.HasPrefix(item.ID, "http://")) {
+			link = item.ID
+		}
+
+		// Feeds without a summary only provide the full content
+		description := cleanFeedDescription(item.Description)
+		if description == "" {
+			description = cleanFeedDescription(item.Content)
+		}
+		if description == "" && len(item.Authors) > 0 {
+			description = "By " + strings.Join(item.Authors, ", ")
+		}
+
+		// Image enclosures stand in for a missing thumbnail
+		imageURL := item.Image
+		for _, enclosure := range item.Enclosures {
+			if imageURL == "" && strings.HasPrefix(enclosure.Type, "image/") {
+				imageURL = enclosure.URL
+			}
+		}
+
+		// Feeds may share GUIDs or titles, so the ID is scoped to the source
+		id := "feed:" + source.Name + ":" + firstNonEmpty(item.ID, link, item.Title)
+
+		newsList = append(newsList, models.News{
+			Title:         item.Title,
+			URL:           link,
+			Description:   description,
+			Source:        label,
+			SubSource:     strings.Join(item.Categories, ", "),
+			PublishedAt:   publishedAt,
+			ID:            id,
+			Author:        strings.Join(item.Authors, ", "),
+			Tags:          item.Categories,
+			Rank:          i + 1,
+			DiscussionURL: item.Comments,
+			ImageURL:      imageURL,
+			Language:      parsed.Language,
+			FetchedAt:     fetchedAt,
+		})
+	}
+
+	// If all articles were skipped, return an error
+	if len(newsList) == 0 && len(skippedArticles) > 0 {
+		return nil, fmt.Errorf("failed to fetch any %s articles, skipped: %v", label, skippedArticles)
+	}
+
+	// Sort by publication date (newest first)
+	sort.SliceStable(newsList, func(i, j int) bool {
+		return newsList[i].PublishedAt.After(newsList[j].PublishedAt)
+	})
+
+	// Limit the number of articles
+	if source.Limit > 0 && len(newsList) > source.Limit {
+		newsList = newsList[:source.Limit]
+	}
+
+	return newsList, nil
+}
+
+// parseFeedDate parses the dates found in feeds: RFC 1123 variants for RSS and RFC 3339 for Atom and JSON Feed
+func parseFeedDate(dateStr string) (time.Time, error) {
+	layouts := []string{
+		time.RFC1123,
+		time.RFC1123Z,
+		"Mon, 2 Jan 2006 15:04:05 MST",
+		"Mon, 2 Jan 2006 15:04:05 -0700",
+		time.RFC3339,
+		"2006-01-02T15:04:05Z",
+		"2006-01-02T15:04:05-07:00",
+	}
+
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(dateStr)); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unable to parse date: %s", dateStr)
+}
+
+// cleanFeedDescription converts an HTML description into plain text and limits its length
+func cleanFeedDescription(desc string) string {
+	return utils.Truncate(utils.HTMLToText(desc), feedDescriptionLength)
+}
+
+// firstNonEmpty returns the first of values that is not blank
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// trimmedStrings returns values with surrounding whitespace removed, dropping blank ones
+func trimmedStrings(values []string) []string {
+	var trimmed []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			trimmed = append(trimmed, value)
+		}
+	}
+	return trimmed
+}
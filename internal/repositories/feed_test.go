@@ -0,0 +1,393 @@
+package repositories
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+)
+
+// mockRSSFeed is an RSS 2.0 feed using the content and Dublin Core extensions
+const mockRSSFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:media="http://search.yahoo.com/mrss/">
+	<channel>
+		<title>The Go Blog</title>
+		<link>https://go.dev/blog</link>
+		<language>en-us</language>
+		<item>
+			<title>Range Over Function Types</title>
+			<link>https://go.dev/blog/range-functions</link>
+			<description></description>
+			<content:encoded><![CDATA[<p>A description of range over function types.</p>]]></content:encoded>
+			<dc:creator>Ian Lance Taylor</dc:creator>
+			<category>Go</category>
+			<category>Iterators</category>
+			<pubDate>Tue, 20 Aug 2024 00:00:00 +0000</pubDate>
+			<guid>tag:blog.golang.org,2013:blog.golang.org/range-functions</guid>
+			<comments>https://go.dev/blog/range-functions#comments</comments>
+			<media:thumbnail url="https://go.dev/blog/range-functions.png"/>
+		</item>
+		<item>
+			<title>Go Time Episode 1</title>
+			<description>Our first episode</description>
+			<author>hosts@gotime.fm (Go Time)</author>
+			<enclosure url="https://example.com/episode1.mp3" type="audio/mpeg" length="1234"/>
+			<pubDate>Wed, 21 Aug 2024 10:00:00 +0000</pubDate>
+		</item>
+	</channel>
+</rss>`
+
+// mockAtomFeed is an Atom 1.0 feed with HTML and inline XHTML text constructs
+const mockAtomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">
+	<title>Martin Fowler</title>
+	<entry>
+		<id>tag:martinfowler.com,2024:Bliki-1</id>
+		<title type="html">Refactoring &amp;amp; Testing</title>
+		<link rel="alternate" type="text/html" href="https://martinfowler.com/bliki/One.html"/>
+		<link rel="enclosure" type="image/png" href="https://martinfowler.com/one.png" length="42"/>
+		<link rel="replies" type="application/atom+xml" href="https://martinfowler.com/bliki/One/comments.atom"/>
+		<link rel="replies" type="text/html" href="https://martinfowler.com/bliki/One.html#comments"/>
+		<author><name>Martin Fowler</name></author>
+		<category term="refactoring"/>
+		<category term="testing" label="Testing"/>
+		<updated>2024-08-01T12:00:00Z</updated>
+		<summary type="html">&lt;p&gt;Short summary&lt;/p&gt;</summary>
+	</entry>
+	<entry>
+		<id>tag:martinfowler.com,2024:Bliki-2</id>
+		<title>Second</title>
+		<link href="https://martinfowler.com/bliki/Two.html"/>
+		<published>2024-08-02T12:00:00+02:00</published>
+		<updated>2024-08-05T12:00:00Z</updated>
+		<content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml">Inline content</div></content>
+	</entry>
+</feed>`
+
+// mockJSONFeed is a JSON Feed 1.1 document
+const mockJSONFeed = `{
+	"version": "https://jsonfeed.org/version/1.1",
+	"title": "AWS What's New",
+	"items": [
+		{
+			"id": "1",
+			"url": "https://aws.amazon.com/new/1",
+			"title": "Amazon S3 adds a feature",
+			"summary": "A new S3 feature",
+			"date_published": "2024-08-10T08:00:00Z",
+			"tags": ["storage", "s3"],
+			"image": "https://aws.amazon.com/1-banner.png",
+			"authors": [{"name": "AWS"}],
+			"attachments": [{"url": "https://aws.amazon.com/1.png", "mime_type": "image/png", "size_in_bytes": 10}]
+		},
+		{
+			"id": "https://aws.amazon.com/new/2",
+			"title": "Amazon EC2 adds a feature",
+			"content_text": "A new EC2 feature",
+			"date_published": "2024-08-11T08:00:00Z"
+		}
+	]
+}`
+
+func TestParseFeed_RSS(t *testing.T) {
+	parsed, err := parseFeed([]byte(mockRSSFeed))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if parsed.Title != "The Go Blog" || parsed.Language != "en-us" || len(parsed.Items) != 2 {
+		t.Fatalf("Expected 2 items of The Go Blog, got %d items of %q", len(parsed.Items), parsed.Title)
+	}
+
+	expected := feedItem{
+		ID:         "tag:blog.golang.org,2013:blog.golang.org/range-functions",
+		Title:      "Range Over Function Types",
+		Link:       "https://go.dev/blog/range-functions",
+		Content:    "<p>A description of range over function types.</p>",
+		Authors:    []string{"Ian Lance Taylor"},
+		Categories: []string{"Go", "Iterators"},
+		Published:  "Tue, 20 Aug 2024 00:00:00 +0000",
+		Comments:   "https://go.dev/blog/range-functions#comments",
+		Image:      "https://go.dev/blog/range-functions.png",
+	}
+	if !reflect.DeepEqual(parsed.Items[0], expected) {
+		t.Errorf("Expected %+v, got %+v", expected, parsed.Items[0])
+	}
+
+	expectedEnclosures := []feedEnclosure{{URL: "https://example.com/episode1.mp3", Type: "audio/mpeg", Length: 1234}}
+	if !reflect.DeepEqual(parsed.Items[1].Enclosures, expectedEnclosures) {
+		t.Errorf("Expected enclosures %+v, got %+v", expectedEnclosures, parsed.Items[1].Enclosures)
+	}
+}
+
+func TestParseFeed_Atom(t *testing.T) {
+	parsed, err := parseFeed([]byte(mockAtomFeed))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if parsed.Title != "Martin Fowler" || parsed.Language != "en" || len(parsed.Items) != 2 {
+		t.Fatalf("Expected 2 items of Martin Fowler, got %d items of %q", len(parsed.Items), parsed.Title)
+	}
+
+	first := parsed.Items[0]
+	if first.Title != "Refactoring & Testing" {
+		t.Errorf("Expected the HTML title as plain text, got %q", first.Title)
+	}
+	if first.Link != "https://martinfowler.com/bliki/One.html" {
+		t.Errorf("Expected the alternate link, got %q", first.Link)
+	}
+	if first.Description != "<p>Short summary</p>" {
+		t.Errorf("Expected the HTML summary, got %q", first.Description)
+	}
+	if !reflect.DeepEqual(first.Categories, []string{"refactoring", "Testing"}) {
+		t.Errorf("Expected categories [refactoring Testing], got %v", first.Categories)
+	}
+	if !reflect.DeepEqual(first.Authors, []string{"Martin Fowler"}) {
+		t.Errorf("Expected author Martin Fowler, got %v", first.Authors)
+	}
+	if len(first.Enclosures) != 1 || first.Enclosures[0].URL != "https://martinfowler.com/one.png" {
+		t.Errorf("Expected the enclosure link, got %+v", first.Enclosures)
+	}
+	if first.Comments != "https://martinfowler.com/bliki/One.html#comments" {
+		t.Errorf("Expected the HTML replies link, got %q", first.Comments)
+	}
+	if first.Published != "2024-08-01T12:00:00Z" {
+		t.Errorf("Expected the updated date when there is no published date, got %q", first.Published)
+	}
+
+	second := parsed.Items[1]
+	if second.Link != "https://martinfowler.com/bliki/Two.html" || second.Published != "2024-08-02T12:00:00+02:00" {
+		t.Errorf("Unexpected second item: %+v", second)
+	}
+	if !strings.Contains(second.Content, "Inline content") {
+		t.Errorf("Expected the XHTML content, got %q", second.Content)
+	}
+}
+
+func TestParseFeed_JSONFeed(t *testing.T) {
+	parsed, err := parseFeed([]byte(mockJSONFeed))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if parsed.Title != "AWS What's New" || len(parsed.Items) != 2 {
+		t.Fatalf("Expected 2 items of AWS What's New, got %d items of %q", len(parsed.Items), parsed.Title)
+	}
+
+	expected := feedItem{
+		ID:          "1",
+		Title:       "Amazon S3 adds a feature",
+		Link:        "https://aws.amazon.com/new/1",
+		Description: "A new S3 feature",
+		Authors:     []string{"AWS"},
+		Categories:  []string{"storage", "s3"},
+		Published:   "2024-08-10T08:00:00Z",
+		Enclosures:  []feedEnclosure{{URL: "https://aws.amazon.com/1.png", Type: "image/png", Length: 10}},
+		Image:       "https://aws.amazon.com/1-banner.png",
+	}
+	if !reflect.DeepEqual(parsed.Items[0], expected) {
+		t.Errorf("Expected %+v, got %+v", expected, parsed.Items[0])
+	}
+}
+
+func TestParseFeed_Unsupported(t *testing.T) {
+	testCases := []string{
+		"",
+		"not a feed",
+		`<?xml version="1.0"?><html><body>Not a feed</body></html>`,
+		`{"version": "1.0", "items": []}`,
+	}
+
+	for _, body := range testCases {
+		if _, err := parseFeed([]byte(body)); err == nil {
+			t.Errorf("%q: expected an error, got nil", body)
+		}
+	}
+}
+
+func TestFeedRepository_Fetch(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		GetWithHeadersFunc: func(url string, headers map[string]string) ([]byte, error) {
+			if url != "https://go.dev/blog/feed.atom" {
+				t.Fatalf("Unexpected URL: %s", url)
+			}
+			if !strings.Contains(headers["Accept"], "application/atom+xml") {
+				t.Errorf("Expected the Accept header to list feed formats, got %q", headers["Accept"])
+			}
+			return []byte(mockRSSFeed), nil
+		},
+	}
+
+	repo := &FeedRepository{httpClient: mockClient}
+	source := models.Source{Name: "GoBlog", Type: "feed", URL: "https://go.dev/blog/feed.atom", Limit: 1}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The newest item is kept, linking to its enclosure
+	if len(news) != 1 {
+		t.Fatalf("Expected 1 news item, got %d", len(news))
+	}
+	if news[0].Title != "Go Time Episode 1" || news[0].URL != "https://example.com/episode1.mp3" {
+		t.Errorf("Expected the newest item linking to its enclosure, got %+v", news[0])
+	}
+	if news[0].Source != "The Go Blog" || news[0].Description != "Our first episode" {
+		t.Errorf("Expected the feed title as source and the description, got %+v", news[0])
+	}
+	if !news[0].PublishedAt.Equal(time.Date(2024, 8, 21, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected published time 2024-08-21 10:00 UTC, got %v", news[0].PublishedAt)
+	}
+}
+
+func TestFeedNews_ContentAndCategories(t *testing.T) {
+	parsed, err := parseFeed([]byte(mockRSSFeed))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	news, err := feedNews(parsed, models.Source{Name: "GoBlog", Limit: 10}, "The Go Blog")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(news) != 2 {
+		t.Fatalf("Expected 2 news items, got %d", len(news))
+	}
+
+	// The older article falls back to its content for the description
+	if news[1].Description != "A description of range over function types." {
+		t.Errorf("Expected the content as description, got %q", news[1].Description)
+	}
+	if news[1].SubSource != "Go, Iterators" {
+		t.Errorf("Expected every category as sub-source, got %q", news[1].SubSource)
+	}
+
+	article := news[1]
+	if article.ID != "feed:GoBlog:tag:blog.golang.org,2013:blog.golang.org/range-functions" || article.Author != "Ian Lance Taylor" ||
+		!reflect.DeepEqual(article.Tags, []string{"Go", "Iterators"}) || article.Rank != 1 || article.Language != "en-us" {
+		t.Errorf("Unexpected article metadata: %+v", article)
+	}
+	if article.DiscussionURL != "https://go.dev/blog/range-functions#comments" || article.ImageURL != "https://go.dev/blog/range-functions.png" {
+		t.Errorf("Expected the comments and thumbnail links, got %+v", article)
+	}
+	if article.FetchedAt.IsZero() {
+		t.Error("Expected the fetch time to be set")
+	}
+
+	// Items without a GUID are identified by their link
+	if news[0].ID != "feed:GoBlog:https://example.com/episode1.mp3" || news[0].Rank != 2 {
+		t.Errorf("Expected the episode to be identified by its link, got %+v", news[0])
+	}
+}
+
+func TestFeedNews_IDScopedToSource(t *testing.T) {
+	// Two feeds reusing the same GUID, and items without a GUID or link sharing a title
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+	<channel>
+		<title>Weekly</title>
+		<item>
+			<title>Weekly update</title>
+			<guid isPermaLink="false">1</guid>
+			<pubDate>Tue, 20 Aug 2024 00:00:00 +0000</pubDate>
+		</item>
+		<item>
+			<title>Release notes</title>
+			<pubDate>Mon, 19 Aug 2024 00:00:00 +0000</pubDate>
+		</item>
+	</channel>
+</rss>`
+	parsed, err := parseFeed([]byte(body))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	first, err := feedNews(parsed, models.Source{Name: "TeamA", Limit: 10}, "Team A")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, err := feedNews(parsed, models.Source{Name: "TeamB", Limit: 10}, "Team B")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(first) != 2 || len(second) != 2 {
+		t.Fatalf("Expected 2 items per feed, got %d and %d", len(first), len(second))
+	}
+	for i := range first {
+		if first[i].ID == second[i].ID {
+			t.Errorf("Expected the feeds to have different IDs, both got %q", first[i].ID)
+		}
+	}
+	if first[0].ID != "feed:TeamA:1" || first[1].ID != "feed:TeamA:Release notes" {
+		t.Errorf("Expected IDs scoped to the source, got %q and %q", first[0].ID, first[1].ID)
+	}
+}
+
+func TestFeedNews_ImageEnclosure(t *testing.T) {
+	parsed, err := parseFeed([]byte(mockAtomFeed))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	news, err := feedNews(parsed, models.Source{Name: "Bliki", Limit: 10}, "Martin Fowler")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// An image enclosure stands in for the thumbnail
+	for _, item := range news {
+		if item.Title == "Refactoring & Testing" && item.ImageURL != "https://martinfowler.com/one.png" {
+			t.Errorf("Expected the image enclosure, got %q", item.ImageURL)
+		}
+	}
+}
+
+func TestParseFeedDate(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected bool // whether parsing should succeed
+	}{
+		{"Thu, 04 Jul 2025 00:00:00 GMT", true},
+		{"Mon, 02 Jan 2006 15:04:05 MST", true},
+		{"2025-07-04T10:30:00Z", true},
+		{"invalid date", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		_, err := parseFeedDate(tc.input)
+		if tc.expected && err != nil {
+			t.Errorf("Expected to parse '%s' successfully, got error: %v", tc.input, err)
+		}
+		if !tc.expected && err == nil {
+			t.Errorf("Expected to fail parsing '%s', but succeeded", tc.input)
+		}
+	}
+}
+
+func TestCleanFeedDescription(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"<p>Simple text</p>", "Simple text"},
+		{"Text with &amp; entities", "Text with & entities"},
+		{"<br/>Line break", "Line break"},
+		{"Very long description that exceeds the maximum length limit and should be truncated to exactly 200 characters plus three dots to indicate that there is more content available but it has been cut off for display purposes", "Very long description that exceeds the maximum length limit and should be truncated to exactly 200 characters plus three dots to indicate that there is more content available but it has been cut off..."},
+		{`<p>Read <a href="https://www.infoq.com/news/">the &#8220;news&#8221;</a><img src="x.png"/></p>`, "Read the “news”"},
+		{strings.Repeat("é", 250), strings.Repeat("é", 200) + "..."},
+	}
+
+	for _, tc := range testCases {
+		result := cleanFeedDescription(tc.input)
+		if result != tc.expected {
+			t.Errorf("Expected '%s', got '%s'", tc.expected, result)
+		}
+	}
+}
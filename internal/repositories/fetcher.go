@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+// Fetcher fetches news items for a configured source
+// Implementations must stop and return an error once ctx is done.
+type Fetcher interface {
+	Fetch(ctx context.Context, source models.Source) ([]models.News, error)
+}
+
+// FetcherFactory creates a Fetcher that uses the given HTTP client
+type FetcherFactory func(httpClient http.HTTPClient) Fetcher
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]FetcherFactory)
+)
+
+// Register registers a fetcher factory for a source type.
+// Source types are case-insensitive. Registering the same type twice replaces the previous factory.
+func Register(sourceType string, factory FetcherFactory) {
+	if factory == nil {
+		panic("repositories: Register factory is nil for source type " + sourceType)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(sourceType)] = factory
+}
+
+// NewFetcher creates a Fetcher for the given source type
+func NewFetcher(sourceType string, httpClient http.HTTPClient) (Fetcher, error) {
+	registryMu.RLock()
+	factory, ok := registry[strings.ToLower(sourceType)]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unsupported source type: %s", sourceType)
+	}
+
+	return factory(httpClient), nil
+}
+
+// RegisteredTypes returns the registered source types in alphabetical order
+func RegisteredTypes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]string, 0, len(registry))
+	for sourceType := range registry {
+		types = append(types, sourceType)
+	}
+	sort.Strings(types)
+
+	return types
+}
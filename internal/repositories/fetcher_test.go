@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+// stubFetcher is a Fetcher used to test the registry
+type stubFetcher struct {
+	httpClient http.HTTPClient
+}
+
+func (f *stubFetcher) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	return nil, nil
+}
+
+func TestNewFetcher_BuiltinTypes(t *testing.T) {
+	for _, sourceType := range []string{"hackernews", "hackernews_algolia", "reddit", "lobsters", "github_releases", "github_trending", "stackexchange", "infoq", "feed", "InfoQ"} {
+		fetcher, err := NewFetcher(sourceType, &MockHTTPClient{})
+		if err != nil {
+			t.Errorf("Expected fetcher for '%s', got error: %v", sourceType, err)
+			continue
+		}
+		if fetcher == nil {
+			t.Errorf("Expected non-nil fetcher for '%s'", sourceType)
+		}
+	}
+}
+
+func TestNewFetcher_Unsupported(t *testing.T) {
+	_, err := NewFetcher("unknown", &MockHTTPClient{})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if err.Error() != "unsupported source type: unknown" {
+		t.Errorf("Expected unsupported source type error, got '%s'", err.Error())
+	}
+}
+
+func TestRegister(t *testing.T) {
+	mockClient := &MockHTTPClient{}
+	Register("Stub", func(httpClient http.HTTPClient) Fetcher {
+		return &stubFetcher{httpClient: httpClient}
+	})
+
+	fetcher, err := NewFetcher("stub", mockClient)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	stub, ok := fetcher.(*stubFetcher)
+	if !ok {
+		t.Fatalf("Expected *stubFetcher, got %T", fetcher)
+	}
+	if stub.httpClient != mockClient {
+		t.Error("Expected the HTTP client to be passed to the factory")
+	}
+
+	found := false
+	for _, sourceType := range RegisteredTypes() {
+		if sourceType == "stub" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected 'stub' in registered types, got %v", RegisteredTypes())
+	}
+}
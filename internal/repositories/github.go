@@ -0,0 +1,342 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/config"
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/utils"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+	"github.com/google/go-github/v60/github"
+)
+
+const (
+	// githubDescriptionLength is the maximum number of characters of a release or repository description
+	githubDescriptionLength = 100
+
+	// githubMaxPerPage is the largest page the GitHub API returns
+	githubMaxPerPage = 100
+
+	// githubMaxReleasePages is the number of pages of releases read at most for a repository
+	githubMaxReleasePages = 10
+)
+
+// GithubReleasesRepository handles fetching the releases published by watched GitHub repositories
+// since the last published run, or within the source time window on the first run
+type GithubReleasesRepository struct {
+	client *github.Client
+}
+
+// GithubTrendingRepository handles fetching the most starred GitHub repositories created
+// within the source time window, optionally narrowed to a language and topics
+type GithubTrendingRepository struct {
+	client *github.Client
+}
+
+// githubTransport sends the requests of the go-github client through the shared HTTP client,
+// so that the GitHub sources are retried, throttled and cached like the other sources
+type githubTransport struct {
+	httpClient http.HTTPClient
+
+	// token authenticates the requests when it is set, to get the higher rate limits
+	token string
+}
+
+func init() {
+	Register("github_releases", func(httpClient http.HTTPClient) Fetcher {
+		return &GithubReleasesRepository{client: newGithubClient(httpClient)}
+	})
+	Register("github_trending", func(httpClient http.HTTPClient) Fetcher {
+		return &GithubTrendingRepository{client: newGithubClient(httpClient)}
+	})
+}
+
+// NewGithubReleasesRepository creates a new GithubReleasesRepository
+func NewGithubReleasesRepository() *GithubReleasesRepository {
+	return &GithubReleasesRepository{client: newGithubClient(http.NewClient())}
+}
+
+// NewGithubTrendingRepository creates a new GithubTrendingRepository
+func NewGithubTrendingRepository() *GithubTrendingRepository {
+	return &GithubTrendingRepository{client: newGithubClient(http.NewClient())}
+}
+
+// newGithubClient creates a GitHub API client sending its requests through httpClient,
+// authenticated with GITHUB_TOKEN when it is set
+func newGithubClient(httpClient http.HTTPClient) *github.Client {
+	transport := &githubTransport{
+		httpClient: httpClient,
+		token:      config.LoadGithubAPIConfig().Token,
+	}
+	return github.NewClient(&nethttp.Client{Transport: transport})
+}
+
+// RoundTrip performs a GET request of the go-github client with the shared HTTP client.
+// An unexpected status is turned back into a response with the body as received,
+// so that go-github reports its API error.
+func (t *githubTransport) RoundTrip(req *nethttp.Request) (*nethttp.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	if req.Method != nethttp.MethodGet {
+		return nil, fmt.Errorf("unsupported GitHub request method %s", req.Method)
+	}
+
+	headers := make(map[string]string, len(req.Header)+1)
+	for key := range req.Header {
+		headers[key] = req.Header.Get(key)
+	}
+	if t.token != "" {
+		headers["Authorization"] = "Bearer " + t.token
+	}
+
+	statusCode, header := nethttp.StatusOK, nethttp.Header{}
+	body, err := t.httpClient.GetWithHeadersContext(req.Context(), req.URL.String(), headers)
+	if err != nil {
+		statusErr, ok := http.AsStatusError(err)
+		if !ok {
+			return nil, err
+		}
+		statusCode, body = statusErr.StatusCode, statusErr.RawBody
+		if statusErr.Header != nil {
+			header = statusErr.Header
+		}
+	}
+
+	return &nethttp.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, nethttp.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Fetch fetches the releases of the repositories listed in the source sub-source, such as
+// "golang/go, kubernetes/kubernetes", newest first
+func (r *GithubReleasesRepository) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	repos, err := githubRepos(source.SubSource)
+	if err != nil {
+		return nil, err
+	}
+
+	window, err := parseTimeWindow(source.TimeWindow)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported GitHub time window: %w", err)
+	}
+
+	// A late or failed run must not lose releases, so read everything since the last published run
+	since := source.Since
+	if since.IsZero() && window > 0 {
+		since = now().Add(-window)
+	}
+
+	var newsList []models.News
+	var skippedRepos []string
+
+	for _, repo := range repos {
+		releases, err := r.fetchReleases(ctx, repo, since)
+		if err != nil {
+			// Stop at once when the run is cancelled
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("failed to fetch GitHub releases: %w", ctx.Err())
+			}
+			// Log warning and continue with other repositories
+			fmt.Printf("WARNING: failed to fetch GitHub releases of %s: %v\n", repo, err)
+			skippedRepos = append(skippedRepos, repo)
+			continue
+		}
+		newsList = append(newsList, releases...)
+	}
+
+	// If all repositories were skipped, return an error
+	if len(skippedRepos) == len(repos) {
+		return nil, fmt.Errorf("failed to fetch any GitHub releases, skipped: %v", skippedRepos)
+	}
+
+	// Sort by publication date (newest first)
+	sort.SliceStable(newsList, func(i, j int) bool {
+		return newsList[i].PublishedAt.After(newsList[j].PublishedAt)
+	})
+
+	if source.Limit > 0 && len(newsList) > source.Limit {
+		newsList = newsList[:source.Limit]
+	}
+
+	return newsList, nil
+}
+
+// fetchReleases fetches the published releases of a repository since the given time, reading the
+// pages of releases, newest first, until an older release is found. Without a start time only the
+// first page is read.
+func (r *GithubReleasesRepository) fetchReleases(ctx context.Context, repo string, since time.Time) ([]models.News, error) {
+	owner, name, _ := strings.Cut(repo, "/")
+
+	var releases []*github.RepositoryRelease
+	opts := &github.ListOptions{PerPage: githubMaxPerPage}
+	for opts.Page = 1; opts.Page <= githubMaxReleasePages; opts.Page++ {
+		page, _, err := r.client.Repositories.ListReleases(ctx, owner, name, opts)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, page...)
+
+		if since.IsZero() || len(page) < githubMaxPerPage || githubReleasesBefore(page, since) {
+			break
+		}
+	}
+
+	fetchedAt := now()
+	var newsList []models.News
+	for i, release := range releases {
+		publishedAt := release.GetPublishedAt().Time
+		if release.GetDraft() || publishedAt.Before(since) {
+			continue
+		}
+
+		// e.g. "golang/go go1.23.0" or "owner/repo v2.0.0: The big rewrite"
+		title := repo + " " + release.GetTagName()
+		if name := strings.TrimSpace(release.GetName()); name != "" && !strings.Contains(name, release.GetTagName()) {
+			title += ": " + name
+		}
+		if release.GetPrerelease() {
+			title += " [pre-release]"
+		}
+
+		newsList = append(newsList, models.News{
+			Title:       title,
+			URL:         release.GetHTMLURL(),
+			Description: utils.Truncate(strings.Join(strings.Fields(release.GetBody()), " "), githubDescriptionLength),
+			Source:      "GitHub Releases",
+			SubSource:   repo,
+			PublishedAt: publishedAt,
+			ID:          "github:" + repo + "@" + release.GetTagName(),
+			Author:      release.GetAuthor().GetLogin(),
+			Rank:        i + 1,
+			FetchedAt:   fetchedAt,
+		})
+	}
+
+	return newsList, nil
+}
+
+// githubReleasesBefore reports whether a page of releases reaches a release published before since
+func githubReleasesBefore(releases []*github.RepositoryRelease, since time.Time) bool {
+	for _, release := range releases {
+		if !release.GetDraft() && release.GetPublishedAt().Time.Before(since) {
+			return true
+		}
+	}
+	return false
+}
+
+// Fetch fetches the most starred repositories created within the source time window.
+// The sub-source selects a language, the tags select topics, and the query adds any search qualifier.
+func (r *GithubTrendingRepository) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	query, err := r.searchQuery(source)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &github.SearchOptions{
+		Sort:        "stars",
+		Order:       "desc",
+		ListOptions: github.ListOptions{PerPage: min(max(source.Limit, 1), githubMaxPerPage)},
+	}
+	result, _, err := r.client.Search.Repositories(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search GitHub repositories: %w", err)
+	}
+
+	fetchedAt := now()
+	var newsList []models.News
+	for i, repo := range result.Repositories {
+		description := utils.Truncate(strings.TrimSpace(repo.GetDescription()), githubDescriptionLength)
+		if description == "" {
+			description = fmt.Sprintf("Stars: %d", repo.GetStargazersCount())
+		}
+
+		newsList = append(newsList, models.News{
+			Title:       repo.GetFullName(),
+			URL:         repo.GetHTMLURL(),
+			Description: description,
+			Source:      "GitHub Trending",
+			SubSource:   repo.GetLanguage(),
+			PublishedAt: repo.GetCreatedAt().Time,
+			Score:       repo.GetStargazersCount(),
+			ID:          "github:" + repo.GetFullName(),
+			Author:      repo.GetOwner().GetLogin(),
+			Tags:        repo.Topics,
+			Rank:        i + 1,
+			ImageURL:    repo.GetOwner().GetAvatarURL(),
+			FetchedAt:   fetchedAt,
+		})
+	}
+
+	if source.Limit > 0 && len(newsList) > source.Limit {
+		newsList = newsList[:source.Limit]
+	}
+
+	return newsList, nil
+}
+
+// searchQuery returns the repository search query of a source, e.g. "created:>=2024-08-20T00:00:00Z language:go topic:cli"
+func (r *GithubTrendingRepository) searchQuery(source models.Source) (string, error) {
+	window, err := parseTimeWindow(source.TimeWindow)
+	if err != nil {
+		return "", fmt.Errorf("unsupported GitHub time window: %w", err)
+	}
+
+	var qualifiers []string
+	if window > 0 {
+		qualifiers = append(qualifiers, "created:>="+now().Add(-window).UTC().Format(time.RFC3339))
+	}
+	if language := strings.TrimSpace(source.SubSource); language != "" {
+		qualifiers = append(qualifiers, "language:"+language)
+	}
+	for _, topic := range source.Tags {
+		qualifiers = append(qualifiers, "topic:"+topic)
+	}
+	if query := strings.TrimSpace(source.Query); query != "" {
+		qualifiers = append(qualifiers, query)
+	}
+
+	// The search API rejects an empty query
+	if len(qualifiers) == 0 {
+		qualifiers = append(qualifiers, "stars:>0")
+	}
+
+	return strings.Join(qualifiers, " "), nil
+}
+
+// githubRepos parses a comma-separated list of "owner/repo" repositories
+func githubRepos(subSource string) ([]string, error) {
+	var repos []string
+	for _, repo := range strings.Split(subSource, ",") {
+		repo = strings.Trim(strings.TrimSpace(repo), "/")
+		if repo == "" {
+			continue
+		}
+		if owner, name, ok := strings.Cut(repo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+			return nil, fmt.Errorf("invalid GitHub repository %q, expected owner/repo", repo)
+		}
+		repos = append(repos, repo)
+	}
+
+	if len(repos) == 0 {
+		return nil, fmt.Errorf("repositories are required for GitHub releases source, e.g. golang/go")
+	}
+
+	return repos, nil
+}
@@ -0,0 +1,311 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+	"github.com/google/go-github/v60/github"
+)
+
+// newTestGithubClient creates a GitHub client sending its requests through a mock HTTP client,
+// handler answers a request of the GitHub API with a JSON body or an error
+func newTestGithubClient(t *testing.T, handler func(u *url.URL, headers map[string]string) (string, error)) *github.Client {
+	t.Setenv("GITHUB_TOKEN", "")
+
+	return newGithubClient(&MockHTTPClient{
+		GetWithHeadersFunc: func(rawURL string, headers map[string]string) ([]byte, error) {
+			u, err := url.Parse(rawURL)
+			if err != nil || u.Host != "api.github.com" {
+				t.Fatalf("Unexpected URL: %s", rawURL)
+			}
+			body, err := handler(u, headers)
+			if err != nil {
+				return nil, err
+			}
+			return []byte(body), nil
+		},
+	})
+}
+
+// githubNotFound is the error of the shared HTTP client for a missing GitHub resource
+func githubNotFound(u *url.URL) error {
+	body := `{"message": "Not Found"}`
+	return &http.StatusError{Method: "GET", URL: u.String(), StatusCode: 404, Body: body, RawBody: []byte(body)}
+}
+
+func TestGithubReleasesRepository_Fetch(t *testing.T) {
+	now := time.Date(2024, 8, 21, 0, 0, 0, 0, time.UTC)
+
+	client := newTestGithubClient(t, func(u *url.URL, headers map[string]string) (string, error) {
+		switch u.Path {
+		case "/repos/golang/go/releases":
+			return `[
+				{"tag_name": "go1.23.0", "name": "go1.23.0", "html_url": "https://github.com/golang/go/releases/tag/go1.23.0",
+				 "body": "Go 1.23\n\nis released", "published_at": "2024-08-20T18:00:00Z"},
+				{"tag_name": "go1.23rc2", "name": "", "html_url": "https://github.com/golang/go/releases/tag/go1.23rc2",
+				 "prerelease": true, "published_at": "2024-08-20T06:00:00Z"},
+				{"tag_name": "go1.22.6", "html_url": "https://github.com/golang/go/releases/tag/go1.22.6",
+				 "published_at": "2024-08-06T18:00:00Z"}
+			]`, nil
+		case "/repos/owner/tool/releases":
+			return `[
+				{"tag_name": "v2.0.0", "name": "The big rewrite", "html_url": "https://github.com/owner/tool/releases/tag/v2.0.0",
+				 "published_at": "2024-08-20T12:00:00Z"},
+				{"tag_name": "v2.1.0", "draft": true}
+			]`, nil
+		default:
+			return "", githubNotFound(u)
+		}
+	})
+
+	setTestNow(t, now)
+	repo := &GithubReleasesRepository{client: client}
+	source := models.Source{Name: "Releases", Type: "github_releases", Limit: 10, SubSource: "golang/go, owner/tool, owner/missing"}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Releases of the last day are kept newest first, drafts and older releases are skipped
+	expectedTitles := []string{
+		"golang/go go1.23.0",
+		"owner/tool v2.0.0: The big rewrite",
+		"golang/go go1.23rc2 [pre-release]",
+	}
+	if len(news) != len(expectedTitles) {
+		t.Fatalf("Expected %d news items, got %d: %+v", len(expectedTitles), len(news), news)
+	}
+	for i, title := range expectedTitles {
+		if news[i].Title != title {
+			t.Errorf("Expected item %d to be '%s', got '%s'", i, title, news[i].Title)
+		}
+	}
+
+	first := news[0]
+	if first.URL != "https://github.com/golang/go/releases/tag/go1.23.0" || first.Description != "Go 1.23 is released" ||
+		first.Source != "GitHub Releases" || first.SubSource != "golang/go" {
+		t.Errorf("Unexpected item: %+v", first)
+	}
+}
+
+func TestGithubReleasesRepository_Fetch_SinceLastRun(t *testing.T) {
+	now := time.Date(2024, 8, 21, 0, 0, 0, 0, time.UTC)
+
+	client := newTestGithubClient(t, func(u *url.URL, headers map[string]string) (string, error) {
+		return `[
+			{"tag_name": "go1.23.0", "html_url": "https://github.com/golang/go/releases/tag/go1.23.0", "published_at": "2024-08-20T18:00:00Z"},
+			{"tag_name": "go1.22.6", "html_url": "https://github.com/golang/go/releases/tag/go1.22.6", "published_at": "2024-08-06T18:00:00Z"},
+			{"tag_name": "go1.22.5", "html_url": "https://github.com/golang/go/releases/tag/go1.22.5", "published_at": "2024-07-02T18:00:00Z"}
+		]`, nil
+	})
+
+	setTestNow(t, now)
+	repo := &GithubReleasesRepository{client: client}
+
+	// The last published run was two weeks ago, the day window must not hide the releases in between
+	source := models.Source{Name: "Releases", Type: "github_releases", Limit: 10, SubSource: "golang/go",
+		Since: time.Date(2024, 8, 6, 0, 0, 0, 0, time.UTC)}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(news) != 2 || news[0].Title != "golang/go go1.23.0" || news[1].Title != "golang/go go1.22.6" {
+		t.Errorf("Expected the releases since the last run, got %+v", news)
+	}
+}
+
+func TestGithubReleasesRepository_Fetch_Pages(t *testing.T) {
+	now := time.Date(2024, 8, 21, 0, 0, 0, 0, time.UTC)
+
+	// A busy repository published a full page of nightly releases since the last run
+	var firstPage []string
+	for i := range githubMaxPerPage {
+		firstPage = append(firstPage, fmt.Sprintf(`{"tag_name": "nightly-%d", "published_at": "%s"}`,
+			i, now.Add(-time.Duration(i+1)*time.Minute).Format(time.RFC3339)))
+	}
+
+	var pages []string
+	client := newTestGithubClient(t, func(u *url.URL, headers map[string]string) (string, error) {
+		query := u.Query()
+		pages = append(pages, query.Get("page"))
+		if query.Get("per_page") != "100" {
+			t.Errorf("Expected pages of 100 releases, got %s", query.Get("per_page"))
+		}
+		switch query.Get("page") {
+		case "1":
+			return "[" + strings.Join(firstPage, ",") + "]", nil
+		case "2":
+			return `[
+				{"tag_name": "v1.1.0", "published_at": "2024-08-19T12:00:00Z"},
+				{"tag_name": "v1.0.0", "published_at": "2024-08-01T12:00:00Z"}
+			]`, nil
+		default:
+			t.Fatalf("Unexpected page: %s", query.Get("page"))
+			return "", nil
+		}
+	})
+
+	setTestNow(t, now)
+	repo := &GithubReleasesRepository{client: client}
+	source := models.Source{Name: "Releases", Type: "github_releases", Limit: 200, SubSource: "owner/busy",
+		Since: time.Date(2024, 8, 18, 0, 0, 0, 0, time.UTC)}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(pages) != 2 {
+		t.Errorf("Expected 2 pages to be read, got %v", pages)
+	}
+	if len(news) != githubMaxPerPage+1 || news[len(news)-1].Title != "owner/busy v1.1.0" {
+		t.Errorf("Expected the releases of both pages since the last run, got %d items", len(news))
+	}
+}
+
+func TestGithubReleasesRepository_Fetch_AllFailed(t *testing.T) {
+	client := newTestGithubClient(t, func(u *url.URL, headers map[string]string) (string, error) {
+		return "", githubNotFound(u)
+	})
+
+	repo := &GithubReleasesRepository{client: client}
+	source := models.Source{Name: "Releases", Type: "github_releases", Limit: 10, SubSource: "owner/missing"}
+
+	_, err := repo.Fetch(context.Background(), source)
+	if err == nil || !strings.Contains(err.Error(), "failed to fetch any GitHub releases") {
+		t.Fatalf("Expected all repositories to be skipped, got %v", err)
+	}
+}
+
+func TestGithubRepos(t *testing.T) {
+	repos, err := githubRepos(" golang/go ,/owner/tool/,")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(repos) != 2 || repos[0] != "golang/go" || repos[1] != "owner/tool" {
+		t.Errorf("Expected [golang/go owner/tool], got %v", repos)
+	}
+
+	for _, subSource := range []string{"", "golang", "golang/go/extra"} {
+		if _, err := githubRepos(subSource); err == nil {
+			t.Errorf("%q: expected an error, got nil", subSource)
+		}
+	}
+}
+
+func TestGithubTrendingRepository_Fetch(t *testing.T) {
+	now := time.Date(2024, 8, 21, 0, 0, 0, 0, time.UTC)
+
+	var query url.Values
+	client := newTestGithubClient(t, func(u *url.URL, headers map[string]string) (string, error) {
+		if u.Path != "/search/repositories" {
+			t.Fatalf("Unexpected path: %s", u.Path)
+		}
+		query = u.Query()
+		return `{"total_count": 2, "items": [
+			{"full_name": "alice/fast-cli", "html_url": "https://github.com/alice/fast-cli", "description": "A fast CLI",
+			 "language": "Go", "stargazers_count": 321, "created_at": "2024-08-20T03:00:00Z"},
+			{"full_name": "bob/tool", "html_url": "https://github.com/bob/tool", "description": null,
+			 "language": "Go", "stargazers_count": 120, "created_at": "2024-08-20T09:00:00Z"}
+		]}`, nil
+	})
+
+	setTestNow(t, now)
+	repo := &GithubTrendingRepository{client: client}
+	source := models.Source{Name: "TrendingGo", Type: "github_trending", Limit: 2, SubSource: "go", Tags: []string{"cli"}}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if q := query.Get("q"); q != "created:>=2024-08-20T00:00:00Z language:go topic:cli" {
+		t.Errorf("Unexpected search query: %s", q)
+	}
+	if query.Get("sort") != "stars" || query.Get("order") != "desc" || query.Get("per_page") != "2" {
+		t.Errorf("Unexpected search options: %v", query)
+	}
+
+	if len(news) != 2 {
+		t.Fatalf("Expected 2 news items, got %d", len(news))
+	}
+	if news[0].Title != "alice/fast-cli" || news[0].Score != 321 || news[0].Description != "A fast CLI" ||
+		news[0].Source != "GitHub Trending" || news[0].SubSource != "Go" {
+		t.Errorf("Unexpected item: %+v", news[0])
+	}
+	if news[1].Description != "Stars: 120" {
+		t.Errorf("Expected the stars description without a repository description, got '%s'", news[1].Description)
+	}
+}
+
+func TestGithubTransport_Authentication(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "test-token")
+
+	var received map[string]string
+	client := newGithubClient(&MockHTTPClient{
+		GetWithHeadersFunc: func(rawURL string, headers map[string]string) ([]byte, error) {
+			received = headers
+			return []byte(`[]`), nil
+		},
+	})
+
+	if _, _, err := client.Repositories.ListReleases(context.Background(), "golang", "go", nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if received["Authorization"] != "Bearer test-token" {
+		t.Errorf("Expected the token to be sent, got %q", received["Authorization"])
+	}
+	if !strings.Contains(received["Accept"], "application/vnd.github") {
+		t.Errorf("Expected the go-github Accept header to be kept, got %q", received["Accept"])
+	}
+}
+
+func TestGithubTransport_StatusError(t *testing.T) {
+	client := newTestGithubClient(t, func(u *url.URL, headers map[string]string) (string, error) {
+		return "", githubNotFound(u)
+	})
+
+	_, resp, err := client.Repositories.ListReleases(context.Background(), "owner", "missing", nil)
+	if resp == nil || resp.StatusCode != 404 {
+		t.Fatalf("Expected a 404 response, got %+v", resp)
+	}
+	if errorResponse, ok := err.(*github.ErrorResponse); !ok || errorResponse.Message != "Not Found" {
+		t.Errorf("Expected the GitHub API error, got %v", err)
+	}
+}
+
+func TestGithubTransport_RateLimitError(t *testing.T) {
+	// The error document is longer than the excerpt of a StatusError and spans several lines
+	body := fmt.Sprintf("{\n  \"message\": \"API rate limit exceeded for 203.0.113.7. %s\",\n"+
+		"  \"documentation_url\": \"https://docs.github.com/rest/overview/resources-in-the-rest-api#rate-limiting\"\n}",
+		strings.Repeat("Authenticated requests get a higher rate limit. ", 12))
+
+	client := newGithubClient(&MockHTTPClient{
+		GetWithHeadersFunc: func(rawURL string, headers map[string]string) ([]byte, error) {
+			return nil, &http.StatusError{
+				Method:     "GET",
+				URL:        rawURL,
+				StatusCode: 403,
+				Header:     map[string][]string{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"1724198400"}},
+				Body:       "{ \"message\": \"API rate limit exceeded",
+				RawBody:    []byte(body),
+			}
+		},
+	})
+
+	_, _, err := client.Repositories.ListReleases(context.Background(), "golang", "go", nil)
+	rateLimitErr, ok := err.(*github.RateLimitError)
+	if !ok {
+		t.Fatalf("Expected a rate limit error, got %v", err)
+	}
+	if !strings.HasPrefix(rateLimitErr.Message, "API rate limit exceeded for 203.0.113.7.") {
+		t.Errorf("Expected the full error message, got '%s'", rateLimitErr.Message)
+	}
+}
@@ -1,37 +1,102 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ducminhgd/gossip-bot/internal/models"
 	"github.com/ducminhgd/gossip-bot/pkg/http"
 )
 
+const (
+	// defaultItemConcurrency is the default number of Hacker News items fetched at the same time
+	defaultItemConcurrency = 8
+
+	// hackerNewsAPIURL is the base URL of the Hacker News Firebase API.
+	// The actual website is https://news.ycombinator.com/
+	hackerNewsAPIURL = "https://hacker-news.firebaseio.com/v0"
+)
+
+// hackerNewsListings maps the supported listings to their story list, "top" is used when none is configured
+var hackerNewsListings = map[string]string{
+	"top":  "topstories",
+	"best": "beststories",
+	"new":  "newstories",
+	"ask":  "askstories",
+	"show": "showstories",
+	"job":  "jobstories",
+}
+
+// hackerNewsChronologicalListings are the listings ordered by time, which are not re-sorted by score
+var hackerNewsChronologicalListings = []string{"new", "job"}
+
 // HackerNewsRepository handles fetching news from Hacker News
 type HackerNewsRepository struct {
 	httpClient http.HTTPClient
+
+	// itemConcurrency is the maximum number of items fetched at the same time
+	itemConcurrency int
+}
+
+func init() {
+	Register("hackernews", func(httpClient http.HTTPClient) Fetcher {
+		return &HackerNewsRepository{
+			httpClient:      httpClient,
+			itemConcurrency: defaultItemConcurrency,
+		}
+	})
 }
 
 // NewHackerNewsRepository creates a new HackerNewsRepository
 func NewHackerNewsRepository() *HackerNewsRepository {
 	return &HackerNewsRepository{
-		httpClient: http.NewClient(),
+		httpClient:      http.NewClient(),
+		itemConcurrency: defaultItemConcurrency,
 	}
 }
 
+// Fetch fetches the stories of the Hacker News list selected by the source listing,
+// top stories by default
+func (r *HackerNewsRepository) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	listName := strings.ToLower(source.Listing)
+	if listName == "" {
+		listName = "top"
+	}
+	if _, ok := hackerNewsListings[listName]; !ok {
+		listNames := make([]string, 0, len(hackerNewsListings))
+		for name := range hackerNewsListings {
+			listNames = append(listNames, name)
+		}
+		sort.Strings(listNames)
+		return nil, fmt.Errorf("unsupported Hacker News listing %q, expected one of %v", listName, listNames)
+	}
+
+	return r.fetchStoryList(ctx, listName, source)
+}
+
 // FetchTopStories fetches top stories from Hacker News
-func (r *HackerNewsRepository) FetchTopStories(source models.Source) ([]models.News, error) {
-	// Hacker News API uses Firebase API
-	// The base URL should be https://hacker-news.firebaseio.com/v0
-	// But the actual website is https://news.ycombinator.com/
+func (r *HackerNewsRepository) FetchTopStories(ctx context.Context, source models.Source) ([]models.News, error) {
+	return r.fetchStoryList(ctx, "top", source)
+}
+
+// FetchBestStories fetches best stories from Hacker News
+func (r *HackerNewsRepository) FetchBestStories(ctx context.Context, source models.Source) ([]models.News, error) {
+	return r.fetchStoryList(ctx, "best", source)
+}
+
+// fetchStoryList fetches the stories of a Hacker News list such as topstories.json
+func (r *HackerNewsRepository) fetchStoryList(ctx context.Context, listName string, source models.Source) ([]models.News, error) {
+	listURL := fmt.Sprintf("%s/%s.json", hackerNewsAPIURL, hackerNewsListings[listName])
 
-	// Fetch top stories IDs
-	topStoriesURL := "https://hacker-news.firebaseio.com/v0/topstories.json"
+	// Fetch story IDs, ordered by rank
 	var storyIDs []int
-	if err := r.httpClient.GetJSON(topStoriesURL, &storyIDs); err != nil {
-		return nil, fmt.Errorf("failed to fetch top stories: %w", err)
+	if err := r.httpClient.GetJSONContext(ctx, listURL, &storyIDs); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s stories: %w", listName, err)
 	}
 
 	// Limit the number of stories
@@ -39,70 +104,65 @@ func (r *HackerNewsRepository) FetchTopStories(source models.Source) ([]models.N
 		storyIDs = storyIDs[:source.Limit]
 	}
 
-	// Fetch each story
-	var newsList []models.News
-	var skippedStories []int
+	// Fetch the stories in parallel, keeping each result at its rank
+	stories := make([]models.News, len(storyIDs))
+	errs := make([]error, len(storyIDs))
 
-	for _, id := range storyIDs {
-		story, err := r.fetchStory(id)
-		if err != nil {
-			// Log warning and continue with other stories
-			fmt.Printf("WARNING: failed to fetch Hacker News story %d: %v\n", id, err)
-			skippedStories = append(skippedStories, id)
-			continue
-		}
-		newsList = append(newsList, story)
+	workers := r.itemConcurrency
+	if workers <= 0 {
+		workers = defaultItemConcurrency
 	}
-
-	// If all stories were skipped, return an error
-	if len(newsList) == 0 && len(skippedStories) > 0 {
-		return nil, fmt.Errorf("failed to fetch any Hacker News top stories, skipped: %v", skippedStories)
+	workers = min(workers, len(storyIDs))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				stories[i], errs[i] = r.fetchStory(ctx, storyIDs[i])
+			}
+		}()
 	}
 
-	// Sort by score
-	sort.Slice(newsList, func(i, j int) bool {
-		return newsList[i].Score > newsList[j].Score
-	})
-
-	return newsList, nil
-}
-
-// FetchBestStories fetches best stories from Hacker News
-func (r *HackerNewsRepository) FetchBestStories(source models.Source) ([]models.News, error) {
-	// Fetch best stories IDs
-	bestStoriesURL := "https://hacker-news.firebaseio.com/v0/beststories.json"
-	var storyIDs []int
-	if err := r.httpClient.GetJSON(bestStoriesURL, &storyIDs); err != nil {
-		return nil, fmt.Errorf("failed to fetch best stories: %w", err)
+	for i := range storyIDs {
+		jobs <- i
 	}
+	close(jobs)
+	wg.Wait()
 
-	// Limit the number of stories
-	if len(storyIDs) > source.Limit {
-		storyIDs = storyIDs[:source.Limit]
+	// Stories skipped because the deadline passed are not worth a partial result
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch Hacker News %s stories: %w", listName, err)
 	}
 
-	// Fetch each story
 	var newsList []models.News
 	var skippedStories []int
 
-	for _, id := range storyIDs {
-		story, err := r.fetchStory(id)
-		if err != nil {
+	for i, id := range storyIDs {
+		if errs[i] != nil {
 			// Log warning and continue with other stories
-			fmt.Printf("WARNING: failed to fetch Hacker News story %d: %v\n", id, err)
+			fmt.Printf("WARNING: failed to fetch Hacker News story %d: %v\n", id, errs[i])
 			skippedStories = append(skippedStories, id)
 			continue
 		}
-		newsList = append(newsList, story)
+		stories[i].Rank = i + 1
+		newsList = append(newsList, stories[i])
 	}
 
 	// If all stories were skipped, return an error
 	if len(newsList) == 0 && len(skippedStories) > 0 {
-		return nil, fmt.Errorf("failed to fetch any Hacker News best stories, skipped: %v", skippedStories)
+		return nil, fmt.Errorf("failed to fetch any Hacker News %s stories, skipped: %v", listName, skippedStories)
+	}
+
+	// Chronological lists keep their order
+	if slices.Contains(hackerNewsChronologicalListings, listName) {
+		return newsList, nil
 	}
 
-	// Sort by score
-	sort.Slice(newsList, func(i, j int) bool {
+	// Sort by score, stories with the same score keep their rank order
+	sort.SliceStable(newsList, func(i, j int) bool {
 		return newsList[i].Score > newsList[j].Score
 	})
 
@@ -110,19 +170,21 @@ func (r *HackerNewsRepository) FetchBestStories(source models.Source) ([]models.
 }
 
 // fetchStory fetches a single story from Hacker News
-func (r *HackerNewsRepository) fetchStory(id int) (models.News, error) {
-	storyURL := fmt.Sprintf("https://hacker-news.firebaseio.com/v0/item/%d.json", id)
+func (r *HackerNewsRepository) fetchStory(ctx context.Context, id int) (models.News, error) {
+	storyURL := fmt.Sprintf("%s/item/%d.json", hackerNewsAPIURL, id)
 	var story map[string]any
-	if err := r.httpClient.GetJSON(storyURL, &story); err != nil {
+	if err := r.httpClient.GetJSONContext(ctx, storyURL, &story); err != nil {
 		return models.News{}, fmt.Errorf("failed to fetch story %d: %w", id, err)
 	}
 
 	// Extract story details
 	title, _ := story["title"].(string)
+	author, _ := story["by"].(string)
+	discussionURL := fmt.Sprintf("https://news.ycombinator.com/item?id=%d", id)
 	url, _ := story["url"].(string)
 	if url == "" {
 		// If the URL is empty, it's a self-post, so use the Hacker News item URL
-		url = fmt.Sprintf("https://news.ycombinator.com/item?id=%d", id)
+		url = discussionURL
 	}
 	score, _ := story["score"].(float64)
 	descendants, _ := story["descendants"].(float64)
@@ -131,13 +193,17 @@ func (r *HackerNewsRepository) fetchStory(id int) (models.News, error) {
 
 	// Create news item
 	news := models.News{
-		Title:       title,
-		URL:         url,
-		Description: fmt.Sprintf("Score: %d, Comments: %d", int(score), int(descendants)),
-		Source:      "Hacker News",
-		PublishedAt: publishedAt,
-		Score:       int(score),
-		Comments:    int(descendants),
+		Title:         title,
+		URL:           url,
+		Description:   fmt.Sprintf("Score: %d, Comments: %d", int(score), int(descendants)),
+		Source:        "Hacker News",
+		PublishedAt:   publishedAt,
+		Score:         int(score),
+		Comments:      int(descendants),
+		ID:            fmt.Sprintf("hackernews:%d", id),
+		Author:        author,
+		DiscussionURL: discussionURL,
+		FetchedAt:     time.Now(),
 	}
 
 	return news, nil
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+const (
+	// hackerNewsAlgoliaURL is the base URL of the Hacker News search API, used when the source has no URL
+	hackerNewsAlgoliaURL = "https://hn.algolia.com/api/v1"
+
+	// hackerNewsAlgoliaMaxHits is the largest page the search API returns
+	hackerNewsAlgoliaMaxHits = 1000
+)
+
+// HackerNewsAlgoliaRepository handles fetching the highest scored Hacker News stories of a time window
+// from the Algolia search API, rather than the snapshot of the front page at fetch time
+type HackerNewsAlgoliaRepository struct {
+	httpClient http.HTTPClient
+}
+
+// hackerNewsAlgoliaResponse is the response of the search endpoint
+type hackerNewsAlgoliaResponse struct {
+	Hits []hackerNewsAlgoliaHit `json:"hits"`
+}
+
+// hackerNewsAlgoliaHit is a story returned by the search endpoint
+type hackerNewsAlgoliaHit struct {
+	ObjectID    string `json:"objectID"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Author      string `json:"author"`
+	Points      int    `json:"points"`
+	NumComments int    `json:"num_comments"`
+	CreatedAt   int64  `json:"created_at_i"`
+}
+
+func init() {
+	Register("hackernews_algolia", func(httpClient http.HTTPClient) Fetcher {
+		return &HackerNewsAlgoliaRepository{
+			httpClient: httpClient,
+		}
+	})
+}
+
+// NewHackerNewsAlgoliaRepository creates a new HackerNewsAlgoliaRepository
+func NewHackerNewsAlgoliaRepository() *HackerNewsAlgoliaRepository {
+	return &HackerNewsAlgoliaRepository{
+		httpClient: http.NewClient(),
+	}
+}
+
+// Fetch fetches the stories created within the source time window, sorted by points.
+// The source query and tags narrow the search, the "story" tag is used when none is configured.
+func (r *HackerNewsAlgoliaRepository) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	searchURL, err := r.searchURL(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var response hackerNewsAlgoliaResponse
+	if err := r.httpClient.GetJSONContext(ctx, searchURL, &response); err != nil {
+		return nil, fmt.Errorf("failed to search Hacker News stories: %w", err)
+	}
+
+	fetchedAt := now()
+	var newsList []models.News
+	for i, hit := range response.Hits {
+		if hit.Title == "" {
+			continue
+		}
+
+		// Stories without a URL are text posts, such as Ask HN
+		itemURL := fmt.Sprintf("https://news.ycombinator.com/item?id=%s", hit.ObjectID)
+		storyURL := hit.URL
+		if storyURL == "" {
+			storyURL = itemURL
+		}
+
+		newsList = append(newsList, models.News{
+			Title:         hit.Title,
+			URL:           storyURL,
+			Description:   fmt.Sprintf("Score: %d, Comments: %d", hit.Points, hit.NumComments),
+			Source:        "Hacker News",
+			PublishedAt:   time.Unix(hit.CreatedAt, 0),
+			Score:         hit.Points,
+			Comments:      hit.NumComments,
+			ID:            "hackernews:" + hit.ObjectID,
+			Author:        hit.Author,
+			Rank:          i + 1,
+			DiscussionURL: itemURL,
+			FetchedAt:     fetchedAt,
+		})
+	}
+
+	// The search API ranks by relevance first, so the stories are sorted by points here
+	sort.SliceStable(newsList, func(i, j int) bool {
+		return newsList[i].Score > newsList[j].Score
+	})
+
+	if source.Limit > 0 && len(newsList) > source.Limit {
+		newsList = newsList[:source.Limit]
+	}
+
+	return newsList, nil
+}
+
+// searchURL returns the search request for a source, e.g.
+// https://hn.algolia.com/api/v1/search?tags=story&numericFilters=created_at_i>1700000000&hitsPerPage=30
+func (r *HackerNewsAlgoliaRepository) searchURL(source models.Source) (string, error) {
+	window, err := parseTimeWindow(source.TimeWindow)
+	if err != nil {
+		return "", fmt.Errorf("unsupported Hacker News time window: %w", err)
+	}
+
+	baseURL := strings.TrimSuffix(source.URL, "/")
+	if baseURL == "" {
+		baseURL = hackerNewsAlgoliaURL
+	}
+
+	// Tags are combined with AND by the search API
+	tags := source.Tags
+	if len(tags) == 0 {
+		tags = []string{"story"}
+	}
+
+	query := url.Values{}
+	query.Set("tags", strings.Join(tags, ","))
+	if source.Query != "" {
+		query.Set("query", source.Query)
+	}
+	if window > 0 {
+		query.Set("numericFilters", fmt.Sprintf("created_at_i>%d", now().Add(-window).Unix()))
+	}
+
+	// Relevance ranking only matters when searching for a query, so more hits are requested
+	// to sort the stories of the window by points without missing any
+	hitsPerPage := source.Limit
+	if source.Query != "" {
+		hitsPerPage = min(hitsPerPage*10, hackerNewsAlgoliaMaxHits)
+	}
+	query.Set("hitsPerPage", strconv.Itoa(hitsPerPage))
+
+	return fmt.Sprintf("%s/search?%s", baseURL, query.Encode()), nil
+}
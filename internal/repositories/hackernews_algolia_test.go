@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+)
+
+// mockAlgoliaSearch holds a link story, an Ask HN story without a link and the most upvoted story last
+const mockAlgoliaSearch = `{
+	"hits": [
+		{"objectID": "101", "title": "Show HN: A tiny Go web framework", "url": "https://example.com/framework", "author": "alice", "points": 150, "num_comments": 40, "created_at_i": 1700000000},
+		{"objectID": "102", "title": "Ask HN: What are you working on?", "url": null, "author": "bob", "points": 300, "num_comments": 500, "created_at_i": 1700003600},
+		{"objectID": "103", "title": "Go 1.22 is released", "url": "https://go.dev/blog/go1.22", "author": "carol", "points": 900, "num_comments": 250, "created_at_i": 1700007200}
+	],
+	"nbHits": 3
+}`
+
+func TestHackerNewsAlgoliaRepository_Fetch(t *testing.T) {
+	now := time.Unix(1700086400, 0)
+
+	var requestedURL string
+	mockClient := &MockHTTPClient{
+		GetJSONFunc: func(url string, v any) error {
+			requestedURL = url
+			return json.Unmarshal([]byte(mockAlgoliaSearch), v)
+		},
+	}
+
+	setTestNow(t, now)
+	repo := &HackerNewsAlgoliaRepository{httpClient: mockClient}
+	source := models.Source{Name: "HackerNewsDaily", Type: "hackernews_algolia", URL: "https://hn.algolia.com/api/v1", Limit: 2}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The stories of the last day are requested
+	parsed, err := url.Parse(requestedURL)
+	if err != nil {
+		t.Fatalf("Failed to parse requested URL: %v", err)
+	}
+	query := parsed.Query()
+	if parsed.Path != "/api/v1/search" || query.Get("tags") != "story" || query.Get("hitsPerPage") != "2" ||
+		query.Get("numericFilters") != "created_at_i>1700000000" {
+		t.Errorf("Unexpected search URL: %s", requestedURL)
+	}
+
+	// Stories are sorted by points and cut to the limit
+	if len(news) != 2 {
+		t.Fatalf("Expected 2 news items, got %d", len(news))
+	}
+	if news[0].Title != "Go 1.22 is released" || news[0].Score != 900 || news[0].Comments != 250 {
+		t.Errorf("Unexpected first item: %+v", news[0])
+	}
+
+	// Text posts link to the Hacker News item
+	if news[1].URL != "https://news.ycombinator.com/item?id=102" {
+		t.Errorf("Expected the item URL for a text post, got %s", news[1].URL)
+	}
+	if !news[1].PublishedAt.Equal(time.Unix(1700003600, 0)) {
+		t.Errorf("Expected published time %v, got %v", time.Unix(1700003600, 0), news[1].PublishedAt)
+	}
+}
+
+func TestHackerNewsAlgoliaRepository_SearchURL(t *testing.T) {
+	now := time.Unix(1700086400, 0)
+	setTestNow(t, now)
+	repo := &HackerNewsAlgoliaRepository{}
+
+	testCases := []struct {
+		source    models.Source
+		expected  string
+		expectErr bool
+	}{
+		{
+			models.Source{Limit: 10, TimeWindow: "all"},
+			"https://hn.algolia.com/api/v1/search?hitsPerPage=10&tags=story",
+			false,
+		},
+		{
+			models.Source{URL: "https://hn.algolia.com/api/v1/", Limit: 10, TimeWindow: "week", Tags: []string{"show_hn"}},
+			"https://hn.algolia.com/api/v1/search?hitsPerPage=10&numericFilters=created_at_i%3E1699481600&tags=show_hn",
+			false,
+		},
+		{
+			models.Source{Limit: 10, TimeWindow: "36h", Query: "golang"},
+			"https://hn.algolia.com/api/v1/search?hitsPerPage=100&numericFilters=created_at_i%3E1699956800&query=golang&tags=story",
+			false,
+		},
+		{models.Source{Limit: 10, TimeWindow: "fortnight"}, "", true},
+		{models.Source{Limit: 10, TimeWindow: "-1h"}, "", true},
+	}
+
+	for _, tc := range testCases {
+		searchURL, err := repo.searchURL(tc.source)
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %s", tc.source.TimeWindow, searchURL)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: expected no error, got %v", tc.source.TimeWindow, err)
+			continue
+		}
+		if searchURL != tc.expected {
+			t.Errorf("%q: expected %s, got %s", tc.source.TimeWindow, tc.expected, searchURL)
+		}
+	}
+}
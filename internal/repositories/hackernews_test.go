@@ -1,11 +1,14 @@
 package repositories
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
 	"reflect"
-	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -14,7 +17,22 @@ import (
 
 // MockHTTPClient is a mock implementation of the HTTP client for testing
 type MockHTTPClient struct {
-	GetJSONFunc func(url string, v any) error
+	GetJSONFunc        func(url string, v any) error
+	GetWithHeadersFunc func(url string, headers map[string]string) ([]byte, error)
+	PostFormFunc       func(url string, data url.Values, headers map[string]string) ([]byte, error)
+	PostJSONFunc       func(url string, payload any, headers map[string]string) ([]byte, error)
+}
+
+// newJSONMockClient returns a mock client answering GetJSON for expectedURL with the JSON document body
+func newJSONMockClient(t *testing.T, expectedURL, body string) *MockHTTPClient {
+	return &MockHTTPClient{
+		GetJSONFunc: func(url string, v any) error {
+			if url != expectedURL {
+				t.Fatalf("Unexpected URL: %s", url)
+			}
+			return json.Unmarshal([]byte(body), v)
+		},
+	}
 }
 
 // Get is a mock implementation of the Get method
@@ -25,7 +43,10 @@ func (m *MockHTTPClient) Get(url string) ([]byte, error) {
 
 // GetWithHeaders is a mock implementation of the GetWithHeaders method
 func (m *MockHTTPClient) GetWithHeaders(url string, headers map[string]string) ([]byte, error) {
-	// This method is not used directly in the tests
+	if m.GetWithHeadersFunc != nil {
+		return m.GetWithHeadersFunc(url, headers)
+	}
+	// This method is not used directly in the hackernews tests
 	return nil, nil
 }
 
@@ -36,136 +57,52 @@ func (m *MockHTTPClient) GetJSON(url string, v any) error {
 
 // PostForm is a mock implementation of the PostForm method
 func (m *MockHTTPClient) PostForm(url string, data url.Values, headers map[string]string) ([]byte, error) {
+	if m.PostFormFunc != nil {
+		return m.PostFormFunc(url, data, headers)
+	}
 	// This method is not used directly in the hackernews tests
 	return nil, nil
 }
 
-// For testing, we'll modify the HackerNewsRepository struct to accept our mock
-// This is a test-only version of the repository
-type testHackerNewsRepository struct {
-	mockClient *MockHTTPClient
-}
-
-// Override the methods we need for testing
-func (r *testHackerNewsRepository) FetchTopStories(source models.Source) ([]models.News, error) {
-	// Fetch top stories IDs
-	topStoriesURL := "https://hacker-news.firebaseio.com/v0/topstories.json"
-	var storyIDs []int
-	if err := r.mockClient.GetJSON(topStoriesURL, &storyIDs); err != nil {
-		return nil, fmt.Errorf("failed to fetch top stories: %w", err)
-	}
-
-	// Limit the number of stories
-	if len(storyIDs) > source.Limit {
-		storyIDs = storyIDs[:source.Limit]
+// PostJSON is a mock implementation of the PostJSON method
+func (m *MockHTTPClient) PostJSON(url string, payload any, headers map[string]string) ([]byte, error) {
+	if m.PostJSONFunc != nil {
+		return m.PostJSONFunc(url, payload, headers)
 	}
-
-	// Fetch each story
-	var newsList []models.News
-	var skippedStories []int
-
-	for _, id := range storyIDs {
-		story, err := r.fetchStory(id)
-		if err != nil {
-			// Log warning and continue with other stories
-			fmt.Printf("WARNING: failed to fetch Hacker News story %d: %v\n", id, err)
-			skippedStories = append(skippedStories, id)
-			continue
-		}
-		newsList = append(newsList, story)
-	}
-
-	// If all stories were skipped, return an error
-	if len(newsList) == 0 && len(skippedStories) > 0 {
-		return nil, fmt.Errorf("failed to fetch any Hacker News top stories, skipped: %v", skippedStories)
-	}
-
-	// Sort by score
-	sort.Slice(newsList, func(i, j int) bool {
-		return newsList[i].Score > newsList[j].Score
-	})
-
-	return newsList, nil
+	// This method is not used directly in the hackernews tests
+	return nil, nil
 }
 
-func (r *testHackerNewsRepository) FetchBestStories(source models.Source) ([]models.News, error) {
-	// Fetch best stories IDs
-	bestStoriesURL := "https://hacker-news.firebaseio.com/v0/beststories.json"
-	var storyIDs []int
-	if err := r.mockClient.GetJSON(bestStoriesURL, &storyIDs); err != nil {
-		return nil, fmt.Errorf("failed to fetch best stories: %w", err)
-	}
-
-	// Limit the number of stories
-	if len(storyIDs) > source.Limit {
-		storyIDs = storyIDs[:source.Limit]
-	}
-
-	// Fetch each story
-	var newsList []models.News
-	var skippedStories []int
-
-	for _, id := range storyIDs {
-		story, err := r.fetchStory(id)
-		if err != nil {
-			// Log warning and continue with other stories
-			fmt.Printf("WARNING: failed to fetch Hacker News story %d: %v\n", id, err)
-			skippedStories = append(skippedStories, id)
-			continue
-		}
-		newsList = append(newsList, story)
-	}
+// GetContext is a mock implementation of the GetContext method
+func (m *MockHTTPClient) GetContext(ctx context.Context, url string) ([]byte, error) {
+	return m.Get(url)
+}
 
-	// If all stories were skipped, return an error
-	if len(newsList) == 0 && len(skippedStories) > 0 {
-		return nil, fmt.Errorf("failed to fetch any Hacker News best stories, skipped: %v", skippedStories)
-	}
+// GetWithHeadersContext is a mock implementation of the GetWithHeadersContext method
+func (m *MockHTTPClient) GetWithHeadersContext(ctx context.Context, url string, headers map[string]string) ([]byte, error) {
+	return m.GetWithHeaders(url, headers)
+}
 
-	// Sort by score
-	sort.Slice(newsList, func(i, j int) bool {
-		return newsList[i].Score > newsList[j].Score
-	})
+// GetJSONContext is a mock implementation of the GetJSONContext method
+func (m *MockHTTPClient) GetJSONContext(ctx context.Context, url string, v any) error {
+	return m.GetJSON(url, v)
+}
 
-	return newsList, nil
+// PostFormContext is a mock implementation of the PostFormContext method
+func (m *MockHTTPClient) PostFormContext(ctx context.Context, url string, data url.Values, headers map[string]string) ([]byte, error) {
+	return m.PostForm(url, data, headers)
 }
 
-func (r *testHackerNewsRepository) fetchStory(id int) (models.News, error) {
-	storyURL := fmt.Sprintf("https://hacker-news.firebaseio.com/v0/item/%d.json", id)
-	var story map[string]any
-	if err := r.mockClient.GetJSON(storyURL, &story); err != nil {
-		return models.News{}, fmt.Errorf("failed to fetch story %d: %w", id, err)
-	}
-
-	// Extract story details
-	title, _ := story["title"].(string)
-	url, _ := story["url"].(string)
-	if url == "" {
-		// If the URL is empty, it's a self-post, so use the Hacker News item URL
-		url = fmt.Sprintf("https://news.ycombinator.com/item?id=%d", id)
-	}
-	score, _ := story["score"].(float64)
-	descendants, _ := story["descendants"].(float64)
-	unixTime, _ := story["time"].(float64)
-	publishedAt := time.Unix(int64(unixTime), 0)
-
-	// Create news item
-	news := models.News{
-		Title:       title,
-		URL:         url,
-		Description: fmt.Sprintf("Score: %d, Comments: %d", int(score), int(descendants)),
-		Source:      "Hacker News",
-		PublishedAt: publishedAt,
-		Score:       int(score),
-		Comments:    int(descendants),
-	}
-
-	return news, nil
+// PostJSONContext is a mock implementation of the PostJSONContext method
+func (m *MockHTTPClient) PostJSONContext(ctx context.Context, url string, payload any, headers map[string]string) ([]byte, error) {
+	return m.PostJSON(url, payload, headers)
 }
 
-// Helper function to create a test repository with a mock client
-func NewHackerNewsRepositoryWithClient(mockClient *MockHTTPClient) *testHackerNewsRepository {
-	return &testHackerNewsRepository{
-		mockClient: mockClient,
+// Helper function to create a repository with a mock client
+func NewHackerNewsRepositoryWithClient(mockClient *MockHTTPClient) *HackerNewsRepository {
+	return &HackerNewsRepository{
+		httpClient:      mockClient,
+		itemConcurrency: defaultItemConcurrency,
 	}
 }
 
@@ -249,7 +186,7 @@ func TestFetchTopStories_Success(t *testing.T) {
 	}
 
 	// Call the method being tested
-	news, err := repo.FetchTopStories(source)
+	news, err := repo.FetchTopStories(context.Background(), source)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -266,14 +203,23 @@ func TestFetchTopStories_Success(t *testing.T) {
 
 	// Check the details of the first news item
 	expectedFirstNews := models.News{
-		Title:       "Test Story 3",
-		URL:         "https://news.ycombinator.com/item?id=789", // Self-post URL
-		Description: "Score: 300, Comments: 30",
-		Source:      "Hacker News",
-		PublishedAt: time.Unix(1625270400, 0),
-		Score:       300,
-		Comments:    30,
-	}
+		Title:         "Test Story 3",
+		URL:           "https://news.ycombinator.com/item?id=789", // Self-post URL
+		Description:   "Score: 300, Comments: 30",
+		Source:        "Hacker News",
+		PublishedAt:   time.Unix(1625270400, 0),
+		Score:         300,
+		Comments:      30,
+		ID:            "hackernews:789",
+		Rank:          3,
+		DiscussionURL: "https://news.ycombinator.com/item?id=789",
+	}
+
+	// The fetch time is the time of the test run
+	if news[0].FetchedAt.IsZero() {
+		t.Error("Expected the fetch time to be set")
+	}
+	news[0].FetchedAt = time.Time{}
 	if !reflect.DeepEqual(news[0], expectedFirstNews) {
 		t.Fatalf("Expected %+v, got %+v", expectedFirstNews, news[0])
 	}
@@ -302,7 +248,7 @@ func TestFetchTopStories_TopStoriesError(t *testing.T) {
 	}
 
 	// Call the method being tested
-	_, err := repo.FetchTopStories(source)
+	_, err := repo.FetchTopStories(context.Background(), source)
 	if err == nil {
 		t.Fatal("Expected an error, got nil")
 	}
@@ -368,7 +314,7 @@ func TestFetchTopStories_StoryError(t *testing.T) {
 	}
 
 	// Call the method being tested
-	news, err := repo.FetchTopStories(source)
+	news, err := repo.FetchTopStories(context.Background(), source)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -449,7 +395,7 @@ func TestFetchBestStories_Success(t *testing.T) {
 	}
 
 	// Call the method being tested
-	news, err := repo.FetchBestStories(source)
+	news, err := repo.FetchBestStories(context.Background(), source)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -488,7 +434,7 @@ func TestFetchBestStories_BestStoriesError(t *testing.T) {
 	}
 
 	// Call the method being tested
-	_, err := repo.FetchBestStories(source)
+	_, err := repo.FetchBestStories(context.Background(), source)
 	if err == nil {
 		t.Fatal("Expected an error, got nil")
 	}
@@ -531,7 +477,7 @@ func TestFetchBestStories_AllStoriesError(t *testing.T) {
 	}
 
 	// Call the method being tested
-	_, err := repo.FetchBestStories(source)
+	_, err := repo.FetchBestStories(context.Background(), source)
 	if err == nil {
 		t.Fatal("Expected an error, got nil")
 	}
@@ -550,6 +496,7 @@ func TestFetchStory_Success(t *testing.T) {
 				*story = map[string]any{
 					"title":       "Test Story",
 					"url":         "https://example.com",
+					"by":          "pg",
 					"score":       float64(100),
 					"descendants": float64(10),
 					"time":        float64(1625097600), // 2021-07-01
@@ -565,21 +512,30 @@ func TestFetchStory_Success(t *testing.T) {
 	repo := NewHackerNewsRepositoryWithClient(mockClient)
 
 	// Call the method being tested
-	news, err := repo.fetchStory(123)
+	news, err := repo.fetchStory(context.Background(), 123)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
 	// Check the results
 	expectedNews := models.News{
-		Title:       "Test Story",
-		URL:         "https://example.com",
-		Description: "Score: 100, Comments: 10",
-		Source:      "Hacker News",
-		PublishedAt: time.Unix(1625097600, 0),
-		Score:       100,
-		Comments:    10,
-	}
+		Title:         "Test Story",
+		URL:           "https://example.com",
+		Description:   "Score: 100, Comments: 10",
+		Source:        "Hacker News",
+		PublishedAt:   time.Unix(1625097600, 0),
+		Score:         100,
+		Comments:      10,
+		ID:            "hackernews:123",
+		Author:        "pg",
+		DiscussionURL: "https://news.ycombinator.com/item?id=123",
+	}
+
+	// The fetch time is the time of the test run
+	if news.FetchedAt.IsZero() {
+		t.Error("Expected the fetch time to be set")
+	}
+	news.FetchedAt = time.Time{}
 	if !reflect.DeepEqual(news, expectedNews) {
 		t.Fatalf("Expected %+v, got %+v", expectedNews, news)
 	}
@@ -597,7 +553,7 @@ func TestFetchStory_Error(t *testing.T) {
 	repo := NewHackerNewsRepositoryWithClient(mockClient)
 
 	// Call the method being tested
-	_, err := repo.fetchStory(123)
+	_, err := repo.fetchStory(context.Background(), 123)
 	if err == nil {
 		t.Fatal("Expected an error, got nil")
 	}
@@ -631,7 +587,7 @@ func TestFetchStory_SelfPost(t *testing.T) {
 	repo := NewHackerNewsRepositoryWithClient(mockClient)
 
 	// Call the method being tested
-	news, err := repo.fetchStory(123)
+	news, err := repo.fetchStory(context.Background(), 123)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -642,3 +598,169 @@ func TestFetchStory_SelfPost(t *testing.T) {
 		t.Fatalf("Expected URL %s, got %s", expectedURL, news.URL)
 	}
 }
+
+func TestFetchTopStories_ParallelKeepsRankOrder(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+
+	// Create a mock HTTP client where every story has the same score,
+	// and later ranks respond faster than earlier ones
+	mockClient := &MockHTTPClient{
+		GetJSONFunc: func(url string, v any) error {
+			if url == "https://hacker-news.firebaseio.com/v0/topstories.json" {
+				*v.(*[]int) = []int{1, 2, 3, 4, 5, 6}
+				return nil
+			}
+
+			mu.Lock()
+			running++
+			maxRunning = max(maxRunning, running)
+			mu.Unlock()
+
+			var id int
+			if _, err := fmt.Sscanf(url, "https://hacker-news.firebaseio.com/v0/item/%d.json", &id); err != nil {
+				return err
+			}
+			time.Sleep(time.Duration(7-id) * 5 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			*v.(*map[string]any) = map[string]any{
+				"title": fmt.Sprintf("Story %d", id),
+				"score": float64(100),
+			}
+			return nil
+		},
+	}
+
+	repo := NewHackerNewsRepositoryWithClient(mockClient)
+	repo.itemConcurrency = 3
+
+	source := models.Source{
+		Name:  "HackerNews",
+		Type:  "hackernews",
+		URL:   "https://hacker-news.firebaseio.com/v0",
+		Limit: 6,
+	}
+
+	news, err := repo.FetchTopStories(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(news) != 6 {
+		t.Fatalf("Expected 6 news items, got %d", len(news))
+	}
+	for i, item := range news {
+		expectedTitle := fmt.Sprintf("Story %d", i+1)
+		if item.Title != expectedTitle {
+			t.Errorf("Expected item %d to be '%s', got '%s'", i, expectedTitle, item.Title)
+		}
+	}
+	if maxRunning > 3 {
+		t.Errorf("Expected at most 3 concurrent item fetches, got %d", maxRunning)
+	}
+}
+
+func TestFetchTopStories_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Create a mock HTTP client that cancels the run while the stories are being fetched
+	mockClient := &MockHTTPClient{
+		GetJSONFunc: func(url string, v any) error {
+			if url == "https://hacker-news.firebaseio.com/v0/topstories.json" {
+				*v.(*[]int) = []int{123}
+				return nil
+			}
+			cancel()
+			*v.(*map[string]any) = map[string]any{"title": "Test Story"}
+			return nil
+		},
+	}
+
+	repo := NewHackerNewsRepositoryWithClient(mockClient)
+	source := models.Source{
+		Name:  "HackerNews",
+		Type:  "hackernews",
+		URL:   "https://hacker-news.firebaseio.com/v0",
+		Limit: 1,
+	}
+
+	_, err := repo.FetchTopStories(ctx, source)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestFetch_Listing(t *testing.T) {
+	testCases := []struct {
+		listing        string
+		expectedURL    string
+		expectedTitles []string
+	}{
+		{"", "https://hacker-news.firebaseio.com/v0/topstories.json", []string{"Story 2", "Story 1"}},
+		{"show", "https://hacker-news.firebaseio.com/v0/showstories.json", []string{"Story 2", "Story 1"}},
+		{"Ask", "https://hacker-news.firebaseio.com/v0/askstories.json", []string{"Story 2", "Story 1"}},
+		// Chronological lists keep their order instead of being sorted by score
+		{"new", "https://hacker-news.firebaseio.com/v0/newstories.json", []string{"Story 1", "Story 2"}},
+		{"job", "https://hacker-news.firebaseio.com/v0/jobstories.json", []string{"Story 1", "Story 2"}},
+	}
+
+	for _, tc := range testCases {
+		var requestedURL string
+		mockClient := &MockHTTPClient{
+			GetJSONFunc: func(url string, v any) error {
+				if ids, ok := v.(*[]int); ok {
+					requestedURL = url
+					*ids = []int{1, 2}
+					return nil
+				}
+
+				var id int
+				if _, err := fmt.Sscanf(url, "https://hacker-news.firebaseio.com/v0/item/%d.json", &id); err != nil {
+					return err
+				}
+				*v.(*map[string]any) = map[string]any{
+					"title": fmt.Sprintf("Story %d", id),
+					"score": float64(id * 10),
+				}
+				return nil
+			},
+		}
+
+		repo := NewHackerNewsRepositoryWithClient(mockClient)
+		source := models.Source{Name: "HackerNews", Type: "hackernews", Limit: 2, Listing: tc.listing}
+
+		news, err := repo.Fetch(context.Background(), source)
+		if err != nil {
+			t.Errorf("%q: expected no error, got %v", tc.listing, err)
+			continue
+		}
+		if requestedURL != tc.expectedURL {
+			t.Errorf("%q: expected list URL %s, got %s", tc.listing, tc.expectedURL, requestedURL)
+		}
+		if len(news) != len(tc.expectedTitles) {
+			t.Errorf("%q: expected %d news items, got %d", tc.listing, len(tc.expectedTitles), len(news))
+			continue
+		}
+		for i, title := range tc.expectedTitles {
+			if news[i].Title != title {
+				t.Errorf("%q: expected item %d to be '%s', got '%s'", tc.listing, i, title, news[i].Title)
+			}
+		}
+	}
+}
+
+func TestFetch_UnsupportedListing(t *testing.T) {
+	repo := NewHackerNewsRepositoryWithClient(&MockHTTPClient{})
+	source := models.Source{Name: "HackerNews", Type: "hackernews", Limit: 2, Listing: "polls"}
+
+	_, err := repo.Fetch(context.Background(), source)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "unsupported Hacker News listing") {
+		t.Errorf("Expected unsupported listing error, got '%s'", err.Error())
+	}
+}
@@ -0,0 +1,206 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/utils"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+const (
+	// hashnodeURL is the GraphQL endpoint of Hashnode, used when the source has no URL
+	hashnodeURL = "https://gql.hashnode.com"
+
+	// hashnodeMaxPosts is the largest page the Hashnode API returns
+	hashnodeMaxPosts = 50
+
+	// hashnodeDescriptionLength is the maximum number of characters of a post description
+	hashnodeDescriptionLength = 100
+
+	// hashnodeTagPostsQuery reads the posts of a tag
+	hashnodeTagPostsQuery = `query TagPosts($slug: String!, $first: Int!, $sortBy: TagPostsSort!) {
+  tag(slug: $slug) {
+    posts(first: $first, filter: {sortBy: $sortBy}) {
+      edges {
+        node {
+          id
+          title
+          url
+          brief
+          reactionCount
+          responseCount
+          publishedAt
+          author { username }
+          coverImage { url }
+          tags { name }
+        }
+      }
+    }
+  }
+}`
+)
+
+// hashnodeListings are the supported sorts of the tag posts, "trending" is used when none is configured
+var hashnodeListings = []string{"trending", "popular", "recent"}
+
+// HashnodeRepository handles fetching the posts of a Hashnode tag through the GraphQL API
+type HashnodeRepository struct {
+	httpClient http.HTTPClient
+}
+
+// hashnodeResponse is the response of the tag posts query
+type hashnodeResponse struct {
+	Data struct {
+		Tag *struct {
+			Posts struct {
+				Edges []struct {
+					Node hashnodePost `json:"node"`
+				} `json:"edges"`
+			} `json:"posts"`
+		} `json:"tag"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// hashnodePost is a post of a Hashnode tag
+type hashnodePost struct {
+	ID            string `json:"id"`
+	Title         string `json:"title"`
+	URL           string `json:"url"`
+	Brief         string `json:"brief"`
+	ReactionCount int    `json:"reactionCount"`
+	ResponseCount int    `json:"responseCount"`
+	PublishedAt   string `json:"publishedAt"`
+	Author        struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	CoverImage *struct {
+		URL string `json:"url"`
+	} `json:"coverImage"`
+	Tags []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+}
+
+func init() {
+	Register("hashnode", func(httpClient http.HTTPClient) Fetcher {
+		return &HashnodeRepository{httpClient: httpClient}
+	})
+}
+
+// NewHashnodeRepository creates a new HashnodeRepository
+func NewHashnodeRepository() *HashnodeRepository {
+	return &HashnodeRepository{
+		httpClient: http.NewClient(),
+	}
+}
+
+// Fetch fetches the posts of the tag given by the source sub-source, such as "go",
+// sorted by the source listing
+func (r *HashnodeRepository) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	tag := strings.ToLower(strings.TrimSpace(source.SubSource))
+	if tag == "" {
+		return nil, fmt.Errorf("tag is required for Hashnode source, e.g. go")
+	}
+
+	listing := strings.ToLower(source.Listing)
+	if listing == "" {
+		listing = "trending"
+	}
+	if !slices.Contains(hashnodeListings, listing) {
+		return nil, fmt.Errorf("unsupported Hashnode listing %q, expected one of %v", listing, hashnodeListings)
+	}
+
+	endpoint := source.URL
+	if endpoint == "" {
+		endpoint = hashnodeURL
+	}
+
+	payload := map[string]any{
+		"query": hashnodeTagPostsQuery,
+		"variables": map[string]any{
+			"slug":   tag,
+			"first":  min(max(source.Limit, 1), hashnodeMaxPosts),
+			"sortBy": listing,
+		},
+	}
+	body, err := r.httpClient.PostJSONContext(ctx, endpoint, payload, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Hashnode posts: %w", err)
+	}
+
+	var response hashnodeResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse Hashnode response: %w", err)
+	}
+
+	// GraphQL reports errors in the body of a successful response
+	if len(response.Errors) > 0 {
+		return nil, fmt.Errorf("hashnode API error: %s", response.Errors[0].Message)
+	}
+	if response.Data.Tag == nil {
+		return nil, fmt.Errorf("hashnode tag %s not found", tag)
+	}
+
+	fetchedAt := time.Now()
+	var newsList []models.News
+	for i, edge := range response.Data.Tag.Posts.Edges {
+		post := edge.Node
+		if strings.TrimSpace(post.Title) == "" {
+			continue
+		}
+
+		publishedAt, err := time.Parse(time.RFC3339, post.PublishedAt)
+		if err != nil {
+			fmt.Printf("WARNING: failed to parse Hashnode post date %s: %v\n", post.PublishedAt, err)
+			publishedAt = time.Now() // Use current time as fallback
+		}
+
+		description := utils.Truncate(utils.HTMLToText(post.Brief), hashnodeDescriptionLength)
+		if description == "" {
+			description = fmt.Sprintf("Reactions: %d, Comments: %d", post.ReactionCount, post.ResponseCount)
+		}
+
+		var tags []string
+		for _, postTag := range post.Tags {
+			tags = append(tags, postTag.Name)
+		}
+
+		imageURL := ""
+		if post.CoverImage != nil {
+			imageURL = post.CoverImage.URL
+		}
+
+		newsList = append(newsList, models.News{
+			Title:         strings.TrimSpace(post.Title),
+			URL:           post.URL,
+			Description:   description,
+			Source:        "Hashnode",
+			SubSource:     strings.Join(tags, ", "),
+			PublishedAt:   publishedAt,
+			Score:         post.ReactionCount,
+			Comments:      post.ResponseCount,
+			ID:            "hashnode:" + post.ID,
+			Author:        post.Author.Username,
+			Tags:          tags,
+			Rank:          i + 1,
+			DiscussionURL: post.URL,
+			ImageURL:      imageURL,
+			FetchedAt:     fetchedAt,
+		})
+	}
+
+	if source.Limit > 0 && len(newsList) > source.Limit {
+		newsList = newsList[:source.Limit]
+	}
+
+	return newsList, nil
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+)
+
+// mockHashnodeTagPosts is the GraphQL answer for the posts of the go tag, one of them without a brief
+const mockHashnodeTagPosts = `{
+	"data": {
+		"tag": {
+			"posts": {
+				"edges": [
+					{
+						"node": {
+							"title": "Building a CLI in Go",
+							"url": "https://gopher.hashnode.dev/building-a-cli-in-go",
+							"brief": "Step by step with cobra &amp; viper.",
+							"reactionCount": 21,
+							"responseCount": 3,
+							"publishedAt": "2024-08-20T08:00:00.000Z",
+							"tags": [{"name": "Go"}, {"name": "CLI"}]
+						}
+					},
+					{
+						"node": {
+							"title": "Go channels explained",
+							"url": "https://gopher.hashnode.dev/go-channels-explained",
+							"brief": "",
+							"reactionCount": 40,
+							"responseCount": 7,
+							"publishedAt": "2024-08-19T12:00:00.000Z",
+							"tags": [{"name": "Go"}]
+						}
+					}
+				]
+			}
+		}
+	}
+}`
+
+func TestHashnodeRepository_Fetch(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		PostJSONFunc: func(url string, payload any, headers map[string]string) ([]byte, error) {
+			if url != "https://gql.hashnode.com" {
+				t.Fatalf("Unexpected URL: %s", url)
+			}
+			variables := payload.(map[string]any)["variables"].(map[string]any)
+			if variables["slug"] != "go" || variables["first"] != 1 || variables["sortBy"] != "popular" {
+				t.Fatalf("Unexpected variables: %v", variables)
+			}
+			return []byte(mockHashnodeTagPosts), nil
+		},
+	}
+	repo := &HashnodeRepository{httpClient: mockClient}
+	source := models.Source{Name: "HashnodeGo", Type: "hashnode", SubSource: " Go ", Listing: "Popular", Limit: 1}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(news) != 1 {
+		t.Fatalf("Expected 1 news item, got %d", len(news))
+	}
+
+	// Posts keep the order of the listing
+	item := news[0]
+	if item.Title != "Building a CLI in Go" || item.URL != "https://gopher.hashnode.dev/building-a-cli-in-go" ||
+		item.Source != "Hashnode" || item.SubSource != "Go, CLI" || item.Score != 21 || item.Comments != 3 {
+		t.Errorf("Unexpected item: %+v", item)
+	}
+	if item.Description != "Step by step with cobra & viper." {
+		t.Errorf("Expected the unescaped brief, got '%s'", item.Description)
+	}
+	if !item.PublishedAt.Equal(time.Date(2024, 8, 20, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected published time 2024-08-20 08:00 UTC, got %v", item.PublishedAt)
+	}
+}
+
+func TestHashnodeRepository_Fetch_Errors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   models.Source
+		response string
+		expected string
+	}{
+		{"missing tag", models.Source{}, "", "tag is required"},
+		{"unsupported listing", models.Source{SubSource: "go", Listing: "hot"}, "", "unsupported Hashnode listing"},
+		{"graphql error", models.Source{SubSource: "go"}, `{"errors": [{"message": "Variable \"$first\" got invalid value"}]}`, "hashnode API error"},
+		{"unknown tag", models.Source{SubSource: "nope"}, `{"data": {"tag": null}}`, "hashnode tag nope not found"},
+	}
+
+	for _, tc := range testCases {
+		mockClient := &MockHTTPClient{
+			PostJSONFunc: func(url string, payload any, headers map[string]string) ([]byte, error) {
+				return []byte(tc.response), nil
+			},
+		}
+		repo := &HashnodeRepository{httpClient: mockClient}
+
+		_, err := repo.Fetch(context.Background(), tc.source)
+		if err == nil || !strings.Contains(err.Error(), tc.expected) {
+			t.Errorf("%s: expected an error containing '%s', got %v", tc.name, tc.expected, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/config"
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/utils"
+)
+
+// HistoryStore records the news items published in earlier digests, so that they are not sent again.
+// A news item matches an entry by its ID or by its canonical URL.
+type HistoryStore interface {
+	// Lookup returns the entry of a news item, if it was published before
+	Lookup(news models.News) (models.HistoryEntry, bool)
+
+	// LastRun returns the start of the last run whose digest was published with the given source
+	LastRun(source string) (time.Time, bool)
+
+	// Commit records the news items and the sources of a digest published by a run started at sentAt,
+	// forgets the entries last published before pruneBefore, and persists the history in a single write
+	Commit(items []models.News, sources []string, sentAt, pruneBefore time.Time) error
+}
+
+// NewHistoryStore creates the HistoryStore of a backend, config.HistoryBackendJSON, backed by the file at path
+func NewHistoryStore(backend, path string) (HistoryStore, error) {
+	switch backend {
+	case config.HistoryBackendJSON:
+		return NewJSONHistoryStore(path)
+	default:
+		return nil, fmt.Errorf("unsupported history backend: %s", backend)
+	}
+}
+
+// JSONHistoryStore is a HistoryStore kept in a single JSON file, small enough to be committed
+// to the repository the bot runs from
+type JSONHistoryStore struct {
+	path string
+
+	mu      sync.Mutex
+	entries []models.HistoryEntry
+	runs    map[string]time.Time
+}
+
+// jsonHistoryFile is the content of the history file
+type jsonHistoryFile struct {
+	Entries []models.HistoryEntry `json:"entries"`
+
+	// Runs is the start of the last published run of each source, by source name
+	Runs map[string]time.Time `json:"runs,omitempty"`
+}
+
+// NewJSONHistoryStore creates a JSONHistoryStore backed by the file at path.
+// A missing file is an empty history, it is created on the first Commit.
+func NewJSONHistoryStore(path string) (*JSONHistoryStore, error) {
+	store := &JSONHistoryStore{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	var file jsonHistoryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse history file %s: %w", path, err)
+	}
+	store.entries = file.Entries
+	store.runs = file.Runs
+
+	return store, nil
+}
+
+// Lookup returns the entry of a news item, if it was published before
+func (s *JSONHistoryStore) Lookup(news models.News) (models.HistoryEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.find(news.ID, utils.CanonicalURL(news.URL)); i >= 0 {
+		return s.entries[i], true
+	}
+	return models.HistoryEntry{}, false
+}
+
+// LastRun returns the start of the last run whose digest was published with the given source
+func (s *JSONHistoryStore) LastRun(source string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	startedAt, ok := s.runs[source]
+	return startedAt, ok
+}
+
+// Commit records the news items and the sources of a digest published by a run started at sentAt,
+// forgets the entries last published before pruneBefore, and writes the history file once
+func (s *JSONHistoryStore) Commit(items []models.News, sources []string, sentAt, pruneBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(pruneBefore)
+	s.record(items, sentAt.UTC())
+
+	if s.runs == nil {
+		s.runs = make(map[string]time.Time)
+	}
+	for _, source := range sources {
+		s.runs[source] = sentAt.UTC()
+	}
+
+	return s.save()
+}
+
+// record records news items as published at sentAt, items published before keep their first publication time
+func (s *JSONHistoryStore) record(items []models.News, sentAt time.Time) {
+	for _, news := range items {
+		canonicalURL := utils.CanonicalURL(news.URL)
+		if news.ID == "" && canonicalURL == "" {
+			continue
+		}
+
+		i := s.find(news.ID, canonicalURL)
+		if i < 0 {
+			s.entries = append(s.entries, models.HistoryEntry{
+				ID:          news.ID,
+				URL:         canonicalURL,
+				Title:       news.Title,
+				FirstSentAt: sentAt,
+				LastSentAt:  sentAt,
+			})
+			continue
+		}
+
+		entry := &s.entries[i]
+		entry.LastSentAt = sentAt
+		if entry.ID == "" {
+			entry.ID = news.ID
+		}
+		if entry.URL == "" {
+			entry.URL = canonicalURL
+		}
+	}
+}
+
+// prune forgets the entries last published before a time
+func (s *JSONHistoryStore) prune(before time.Time) {
+	entries := s.entries[:0]
+	for _, entry := range s.entries {
+		if !entry.LastSentAt.Before(before) {
+			entries = append(entries, entry)
+		}
+	}
+	s.entries = entries
+}
+
+// find returns the index of the entry with the given ID or canonical URL, or -1
+func (s *JSONHistoryStore) find(id, canonicalURL string) int {
+	for i, entry := range s.entries {
+		if (id != "" && entry.ID == id) || (canonicalURL != "" && entry.URL == canonicalURL) {
+			return i
+		}
+	}
+	return -1
+}
+
+// save writes the entries to the history file, oldest first so that the file diffs well
+func (s *JSONHistoryStore) save() error {
+	sort.SliceStable(s.entries, func(i, j int) bool {
+		return s.entries[i].FirstSentAt.Before(s.entries[j].FirstSentAt)
+	})
+
+	file := jsonHistoryFile{Entries: s.entries, Runs: s.runs}
+	if file.Entries == nil {
+		file.Entries = []models.HistoryEntry{}
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	// Write to a temporary file first so that a failed write never truncates the history
+	tmp, err := os.CreateTemp(dir, "history-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create history file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to store history file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/config"
+	"github.com/ducminhgd/gossip-bot/internal/models"
+)
+
+func TestJSONHistoryStore_RecordAndLookup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "history.json")
+
+	store, err := NewJSONHistoryStore(path)
+	if err != nil {
+		t.Fatalf("Expected a missing file to be an empty history, got %v", err)
+	}
+
+	story := models.News{ID: "hackernews:1", Title: "Go 1.23 is released", URL: "https://go.dev/blog/go1.23"}
+	if _, ok := store.Lookup(story); ok {
+		t.Error("Expected an empty history")
+	}
+
+	firstRun := time.Date(2024, 8, 20, 0, 0, 0, 0, time.UTC)
+	items := []models.News{story, {Title: "No ID or link"}}
+	if err := store.Commit(items, nil, firstRun, time.Time{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The same link under another ID is recognised
+	repost := models.News{ID: "reddit:t3_9", Title: "Go 1.23 released", URL: "http://www.go.dev/blog/go1.23/?utm_source=reddit"}
+	entry, ok := store.Lookup(repost)
+	if !ok {
+		t.Fatal("Expected the repost to be found by its link")
+	}
+	expected := models.HistoryEntry{
+		ID:          "hackernews:1",
+		URL:         "https://go.dev/blog/go1.23",
+		Title:       "Go 1.23 is released",
+		FirstSentAt: firstRun,
+		LastSentAt:  firstRun,
+	}
+	if !reflect.DeepEqual(entry, expected) {
+		t.Errorf("Expected entry %+v, got %+v", expected, entry)
+	}
+
+	secondRun := firstRun.Add(24 * time.Hour)
+	if err := store.Commit([]models.News{story}, nil, secondRun, time.Time{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The history is read back from the file
+	reloaded, err := NewJSONHistoryStore(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	entry, ok = reloaded.Lookup(story)
+	if !ok || !entry.FirstSentAt.Equal(firstRun) || !entry.LastSentAt.Equal(secondRun) {
+		t.Errorf("Expected the first and last publication times to be kept, got %+v", entry)
+	}
+	if len(reloaded.entries) != 1 {
+		t.Errorf("Expected 1 entry, got %+v", reloaded.entries)
+	}
+}
+
+func TestJSONHistoryStore_Prune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	store, err := NewJSONHistoryStore(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	day := time.Date(2024, 8, 20, 0, 0, 0, 0, time.UTC)
+	old := models.News{ID: "lobsters:old", URL: "https://example.com/old"}
+	recent := models.News{ID: "lobsters:recent", URL: "https://example.com/recent"}
+	if err := store.Commit([]models.News{old}, nil, day, day.Add(-72*time.Hour)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The entries older than the window are forgotten by the commit of the next run
+	if err := store.Commit([]models.News{recent}, nil, day.Add(72*time.Hour), day.Add(24*time.Hour)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := store.Lookup(old); ok {
+		t.Error("Expected the old entry to be forgotten")
+	}
+	if _, ok := store.Lookup(recent); !ok {
+		t.Error("Expected the recent entry to be kept")
+	}
+
+	if err := store.Commit(nil, nil, day.Add(100*time.Hour), day.Add(100*time.Hour)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(string(data), `"entries": []`) {
+		t.Errorf("Expected an empty list of entries, got %s", data)
+	}
+}
+
+func TestJSONHistoryStore_Runs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	store, err := NewJSONHistoryStore(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, ok := store.LastRun("Releases"); ok {
+		t.Error("Expected no run before the first one")
+	}
+
+	startedAt := time.Date(2024, 8, 20, 0, 0, 0, 0, time.UTC)
+	story := models.News{ID: "hackernews:1", URL: "https://go.dev/blog/go1.23"}
+	if err := store.Commit([]models.News{story}, []string{"Releases", "HackerNews"}, startedAt, time.Time{}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The items and runs of a commit are read back from the file
+	reloaded, err := NewJSONHistoryStore(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if lastRun, ok := reloaded.LastRun("Releases"); !ok || !lastRun.Equal(startedAt) {
+		t.Errorf("Expected the run of %v, got %v", startedAt, lastRun)
+	}
+	if _, ok := reloaded.LastRun("Lobsters"); ok {
+		t.Error("Expected no run of a source that was not recorded")
+	}
+	if _, ok := reloaded.Lookup(story); !ok {
+		t.Error("Expected the item of the run to be recorded")
+	}
+}
+
+func TestNewJSONHistoryStore_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	if err := os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := NewJSONHistoryStore(path); err == nil {
+		t.Error("Expected an error for a corrupt history file")
+	}
+}
+
+func TestNewHistoryStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	store, err := NewHistoryStore(config.HistoryBackendJSON, path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok := store.(*JSONHistoryStore); !ok {
+		t.Errorf("Expected a JSON history store, got %T", store)
+	}
+
+	if _, err := NewHistoryStore("sqlite", path); err == nil {
+		t.Error("Expected an error for an unsupported backend")
+	}
+}
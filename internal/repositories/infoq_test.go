@@ -1,7 +1,7 @@
 package repositories
 
 import (
-	"encoding/xml"
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -10,89 +10,22 @@ import (
 	"github.com/ducminhgd/gossip-bot/internal/models"
 )
 
-// MockHTTPClient for testing InfoQ repository
-type mockInfoQHTTPClient struct {
-	responses map[string][]byte
-	errors    map[string]error
-}
-
-func (m *mockInfoQHTTPClient) Get(url string) ([]byte, error) {
-	if err, exists := m.errors[url]; exists {
-		return nil, err
-	}
-	if response, exists := m.responses[url]; exists {
-		return response, nil
-	}
-	return nil, fmt.Errorf("no mock response for URL: %s", url)
-}
-
-func (m *mockInfoQHTTPClient) GetWithHeaders(url string, headers map[string]string) ([]byte, error) {
-	return m.Get(url)
-}
-
-func (m *mockInfoQHTTPClient) GetJSON(url string, v any) error {
-	body, err := m.Get(url)
-	if err != nil {
-		return err
-	}
-	return xml.Unmarshal(body, v)
-}
-
-func (m *mockInfoQHTTPClient) PostForm(url string, data map[string][]string, headers map[string]string) ([]byte, error) {
-	return nil, fmt.Errorf("PostForm not implemented in mock")
-}
-
-// testInfoQRepository for testing
-type testInfoQRepository struct {
-	mockClient *mockInfoQHTTPClient
-}
-
-func newTestInfoQRepository(mockClient *mockInfoQHTTPClient) *testInfoQRepository {
-	return &testInfoQRepository{
-		mockClient: mockClient,
-	}
-}
-
-func (r *testInfoQRepository) FetchArticles(source models.Source) ([]models.News, error) {
-	// Fetch RSS feed with appropriate headers
-	headers := map[string]string{
-		"Accept":     "application/rss+xml, application/xml, text/xml, */*",
-		"User-Agent": "GossipBot/1.0 RSS Reader (https://github.com/ducminhgd/gossip-bot)",
-	}
-	body, err := r.mockClient.GetWithHeaders(source.URL, headers)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch InfoQ RSS feed: %w", err)
-	}
-
-	// Parse RSS feed
-	var feed InfoQRSSFeed
-	if err := xml.Unmarshal(body, &feed); err != nil {
-		return nil, fmt.Errorf("failed to parse InfoQ RSS feed: %w", err)
-	}
-
-	// Convert RSS items to news items (simplified for testing)
-	var newsList []models.News
-	for i, item := range feed.Channel.Items {
-		if i >= source.Limit {
-			break
-		}
-
-		publishedAt, _ := time.Parse(time.RFC1123, item.PubDate)
-
-		news := models.News{
-			Title:       item.Title,
-			URL:         item.Link,
-			Description: item.Description,
-			Source:      "InfoQ",
-			SubSource:   item.Category,
-			PublishedAt: publishedAt,
-			Score:       0,
-			Comments:    0,
-		}
-		newsList = append(newsList, news)
+// newInfoQMockClient returns a mock client serving body, or failing with err, for the InfoQ feed URL
+func newInfoQMockClient(t *testing.T, body string, err error) *MockHTTPClient {
+	return &MockHTTPClient{
+		GetWithHeadersFunc: func(url string, headers map[string]string) ([]byte, error) {
+			if url != "https://feed.infoq.com/" {
+				t.Fatalf("Unexpected URL: %s", url)
+			}
+			if !strings.Contains(headers["Accept"], "application/rss+xml") {
+				t.Errorf("Expected an RSS Accept header, got %q", headers["Accept"])
+			}
+			if err != nil {
+				return nil, err
+			}
+			return []byte(body), nil
+		},
 	}
-
-	return newsList, nil
 }
 
 func TestInfoQRepository_FetchArticles(t *testing.T) {
@@ -122,14 +55,7 @@ func TestInfoQRepository_FetchArticles(t *testing.T) {
 	</channel>
 </rss>`
 
-	mockClient := &mockInfoQHTTPClient{
-		responses: map[string][]byte{
-			"https://feed.infoq.com/": []byte(mockRSSResponse),
-		},
-		errors: make(map[string]error),
-	}
-
-	repo := newTestInfoQRepository(mockClient)
+	repo := &InfoQRepository{httpClient: newInfoQMockClient(t, mockRSSResponse, nil)}
 	source := models.Source{
 		Name:  "InfoQ",
 		Type:  "infoq",
@@ -137,7 +63,7 @@ func TestInfoQRepository_FetchArticles(t *testing.T) {
 		Limit: 10,
 	}
 
-	articles, err := repo.FetchArticles(source)
+	articles, err := repo.FetchArticles(context.Background(), source)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
@@ -146,8 +72,8 @@ func TestInfoQRepository_FetchArticles(t *testing.T) {
 		t.Fatalf("Expected 2 articles, got %d", len(articles))
 	}
 
-	// Check first article
-	firstArticle := articles[0]
+	// Articles are sorted newest first
+	firstArticle := articles[1]
 	if firstArticle.Title != "Effective Practices for Coding with a Chat-Based AI" {
 		t.Errorf("Expected title 'Effective Practices for Coding with a Chat-Based AI', got '%s'", firstArticle.Title)
 	}
@@ -157,17 +83,13 @@ func TestInfoQRepository_FetchArticles(t *testing.T) {
 	if firstArticle.SubSource != "Development" {
 		t.Errorf("Expected sub-source 'Development', got '%s'", firstArticle.SubSource)
 	}
+	if !firstArticle.PublishedAt.Equal(time.Date(2025, 7, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected published time 2025-07-04 00:00 UTC, got %v", firstArticle.PublishedAt)
+	}
 }
 
 func TestInfoQRepository_FetchArticles_NetworkError(t *testing.T) {
-	mockClient := &mockInfoQHTTPClient{
-		responses: make(map[string][]byte),
-		errors: map[string]error{
-			"https://feed.infoq.com/": fmt.Errorf("network error"),
-		},
-	}
-
-	repo := newTestInfoQRepository(mockClient)
+	repo := &InfoQRepository{httpClient: newInfoQMockClient(t, "", fmt.Errorf("network error"))}
 	source := models.Source{
 		Name:  "InfoQ",
 		Type:  "infoq",
@@ -175,7 +97,7 @@ func TestInfoQRepository_FetchArticles_NetworkError(t *testing.T) {
 		Limit: 10,
 	}
 
-	_, err := repo.FetchArticles(source)
+	_, err := repo.FetchArticles(context.Background(), source)
 	if err == nil {
 		t.Fatal("Expected error, got nil")
 	}
@@ -185,14 +107,7 @@ func TestInfoQRepository_FetchArticles_NetworkError(t *testing.T) {
 }
 
 func TestInfoQRepository_FetchArticles_InvalidXML(t *testing.T) {
-	mockClient := &mockInfoQHTTPClient{
-		responses: map[string][]byte{
-			"https://feed.infoq.com/": []byte("invalid xml"),
-		},
-		errors: make(map[string]error),
-	}
-
-	repo := newTestInfoQRepository(mockClient)
+	repo := &InfoQRepository{httpClient: newInfoQMockClient(t, "invalid xml", nil)}
 	source := models.Source{
 		Name:  "InfoQ",
 		Type:  "infoq",
@@ -200,7 +115,7 @@ func TestInfoQRepository_FetchArticles_InvalidXML(t *testing.T) {
 		Limit: 10,
 	}
 
-	_, err := repo.FetchArticles(source)
+	_, err := repo.FetchArticles(context.Background(), source)
 	if err == nil {
 		t.Fatal("Expected error, got nil")
 	}
@@ -208,49 +123,3 @@ func TestInfoQRepository_FetchArticles_InvalidXML(t *testing.T) {
 		t.Errorf("Expected parse error, got '%s'", err.Error())
 	}
 }
-
-func TestInfoQRepository_parseInfoQDate(t *testing.T) {
-	repo := NewInfoQRepository()
-
-	testCases := []struct {
-		input    string
-		expected bool // whether parsing should succeed
-	}{
-		{"Thu, 04 Jul 2025 00:00:00 GMT", true},
-		{"Mon, 02 Jan 2006 15:04:05 MST", true},
-		{"2025-07-04T10:30:00Z", true},
-		{"invalid date", false},
-		{"", false},
-	}
-
-	for _, tc := range testCases {
-		_, err := repo.parseInfoQDate(tc.input)
-		if tc.expected && err != nil {
-			t.Errorf("Expected to parse '%s' successfully, got error: %v", tc.input, err)
-		}
-		if !tc.expected && err == nil {
-			t.Errorf("Expected to fail parsing '%s', but succeeded", tc.input)
-		}
-	}
-}
-
-func TestInfoQRepository_cleanDescription(t *testing.T) {
-	repo := NewInfoQRepository()
-
-	testCases := []struct {
-		input    string
-		expected string
-	}{
-		{"<p>Simple text</p>", "Simple text"},
-		{"Text with &amp; entities", "Text with & entities"},
-		{"<br/>Line break", " Line break"},
-		{"Very long description that exceeds the maximum length limit and should be truncated to exactly 200 characters plus three dots to indicate that there is more content available but it has been cut off for display purposes", "Very long description that exceeds the maximum length limit and should be truncated to exactly 200 characters plus three dots to indicate that there is more content available but it has been cut..."},
-	}
-
-	for _, tc := range testCases {
-		result := repo.cleanDescription(tc.input)
-		if result != tc.expected {
-			t.Errorf("Expected '%s', got '%s'", tc.expected, result)
-		}
-	}
-}
@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/utils"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+const (
+	// lobstersURL is the base URL of Lobsters, used when the source has no URL
+	lobstersURL = "https://lobste.rs"
+
+	// lobstersDescriptionLength is the maximum number of characters of a story description
+	lobstersDescriptionLength = 100
+)
+
+// lobstersListings are the supported listings, "hottest" is used when none is configured
+var lobstersListings = []string{"hottest", "newest"}
+
+// LobstersRepository handles fetching stories from Lobsters
+type LobstersRepository struct {
+	httpClient http.HTTPClient
+}
+
+// lobstersStory is a story of a Lobsters listing
+type lobstersStory struct {
+	ShortID          string   `json:"short_id"`
+	ShortIDURL       string   `json:"short_id_url"`
+	CreatedAt        string   `json:"created_at"`
+	Title            string   `json:"title"`
+	URL              string   `json:"url"`
+	Score            int      `json:"score"`
+	CommentCount     int      `json:"comment_count"`
+	Description      string   `json:"description"`
+	DescriptionPlain string   `json:"description_plain"`
+	CommentsURL      string   `json:"comments_url"`
+	SubmitterUser    string   `json:"submitter_user"`
+	Tags             []string `json:"tags"`
+}
+
+func init() {
+	Register("lobsters", func(httpClient http.HTTPClient) Fetcher {
+		return &LobstersRepository{httpClient: httpClient}
+	})
+}
+
+// NewLobstersRepository creates a new LobstersRepository
+func NewLobstersRepository() *LobstersRepository {
+	return &LobstersRepository{
+		httpClient: http.NewClient(),
+	}
+}
+
+// Fetch fetches the stories of the Lobsters listing configured for the source.
+// A sub-source such as "go,rust" reads the stories of these tags instead.
+func (r *LobstersRepository) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	listingURL, listing, err := lobstersListingURL(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var stories []lobstersStory
+	if err := r.httpClient.GetJSONContext(ctx, listingURL, &stories); err != nil {
+		return nil, fmt.Errorf("failed to fetch Lobsters %s stories: %w", listing, err)
+	}
+
+	var newsList []models.News
+	for i, story := range stories {
+		if story.Title == "" || !lobstersStoryAllowed(story, source.Filter) {
+			continue
+		}
+
+		// Keep the first stories of the listing once the limit is reached
+		if source.Limit > 0 && len(newsList) >= source.Limit {
+			break
+		}
+
+		news := lobstersNews(story)
+		news.Rank = i + 1
+		newsList = append(newsList, news)
+	}
+
+	// The newest listing is ordered by time
+	if listing == "newest" {
+		return newsList, nil
+	}
+
+	// Sort by score, stories with the same score keep their rank order
+	sort.SliceStable(newsList, func(i, j int) bool {
+		return newsList[i].Score > newsList[j].Score
+	})
+
+	return newsList, nil
+}
+
+// lobstersListingURL returns the URL of the listing configured for a source and the name of the listing,
+// e.g. "https://lobste.rs/hottest.json" or "https://lobste.rs/t/go,rust.json"
+func lobstersListingURL(source models.Source) (string, string, error) {
+	baseURL := strings.TrimSuffix(source.URL, "/")
+	if baseURL == "" {
+		baseURL = lobstersURL
+	}
+
+	listing := strings.ToLower(source.Listing)
+	if listing == "" {
+		listing = "hottest"
+	}
+	if !slices.Contains(lobstersListings, listing) {
+		return "", "", fmt.Errorf("unsupported Lobsters listing %q, expected one of %v", listing, lobstersListings)
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(source.SubSource, ",") {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, url.PathEscape(tag))
+		}
+	}
+	if len(tags) == 0 {
+		return fmt.Sprintf("%s/%s.json", baseURL, listing), listing, nil
+	}
+
+	// Tag pages are only ranked by hotness
+	if listing != "hottest" {
+		return "", "", fmt.Errorf("lobsters tags are only supported by the hottest listing, got listing %q", listing)
+	}
+
+	tagList := strings.Join(tags, ",")
+	return fmt.Sprintf("%s/t/%s.json", baseURL, tagList), "tagged " + tagList, nil
+}
+
+// lobstersStoryAllowed reports whether a story passes the tag rules of the filter
+func lobstersStoryAllowed(story lobstersStory, filter models.PostFilter) bool {
+	if len(filter.IncludeTags) > 0 && !tagsMatch(story.Tags, filter.IncludeTags) {
+		return false
+	}
+	return !tagsMatch(story.Tags, filter.ExcludeTags)
+}
+
+// tagsMatch reports whether any of the tags is one of the wanted tags, ignoring case
+func tagsMatch(tags, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if strings.EqualFold(strings.TrimSpace(tag), strings.TrimSpace(w)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// lobstersNews converts a Lobsters story into a news item
+func lobstersNews(story lobstersStory) models.News {
+	// Text posts have no URL, they link to their discussion
+	storyURL := story.URL
+	if storyURL == "" {
+		storyURL = firstNonEmpty(story.CommentsURL, story.ShortIDURL)
+	}
+
+	description := story.DescriptionPlain
+	if description == "" {
+		description = utils.HTMLToText(story.Description)
+	}
+	description = utils.Truncate(strings.Join(strings.Fields(description), " "), lobstersDescriptionLength)
+	if description == "" {
+		description = fmt.Sprintf("Score: %d, Comments: %d", story.Score, story.CommentCount)
+	}
+
+	publishedAt, err := time.Parse(time.RFC3339, story.CreatedAt)
+	if err != nil {
+		fmt.Printf("WARNING: failed to parse Lobsters story date %s: %v\n", story.CreatedAt, err)
+		publishedAt = time.Now() // Use current time as fallback
+	}
+
+	return models.News{
+		Title:         story.Title,
+		URL:           storyURL,
+		Description:   description,
+		Source:        "Lobsters",
+		SubSource:     strings.Join(story.Tags, ", "),
+		PublishedAt:   publishedAt,
+		Score:         story.Score,
+		Comments:      story.CommentCount,
+		ID:            "lobsters:" + story.ShortID,
+		Author:        story.SubmitterUser,
+		Tags:          story.Tags,
+		DiscussionURL: firstNonEmpty(story.CommentsURL, story.ShortIDURL),
+		FetchedAt:     time.Now(),
+	}
+}
@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+)
+
+// mockLobstersHottest has a link story, a text-only weekly thread and a higher scored story listed last,
+// dated with the UTC offset of the site
+const mockLobstersHottest = `[
+	{
+		"short_id": "abc123",
+		"short_id_url": "https://lobste.rs/s/abc123",
+		"created_at": "2024-08-20T09:15:00.000-05:00",
+		"title": "Go 1.23 is released",
+		"url": "https://go.dev/blog/go1.23",
+		"score": 42,
+		"comment_count": 12,
+		"description": "",
+		"description_plain": "",
+		"comments_url": "https://lobste.rs/s/abc123/go_1_23_is_released",
+		"submitter_user": "gopher",
+		"tags": ["go", "release"]
+	},
+	{
+		"short_id": "def456",
+		"short_id_url": "https://lobste.rs/s/def456",
+		"created_at": "2024-08-20T10:30:00.000-05:00",
+		"title": "What are you doing this week?",
+		"url": "",
+		"score": 15,
+		"comment_count": 30,
+		"description": "<p>Feel free to tell what you plan on doing this week &amp; any help you need.</p>",
+		"description_plain": "Feel free to tell what you plan on doing this week & any help you need.",
+		"comments_url": "https://lobste.rs/s/def456/what_are_you_doing_this_week",
+		"submitter_user": "mods",
+		"tags": ["ask"]
+	},
+	{
+		"short_id": "ghi789",
+		"short_id_url": "https://lobste.rs/s/ghi789",
+		"created_at": "2024-08-20T11:00:00.000-05:00",
+		"title": "Rust in the kernel",
+		"url": "https://lwn.net/Articles/1/",
+		"score": 60,
+		"comment_count": 25,
+		"description": "",
+		"description_plain": "",
+		"comments_url": "https://lobste.rs/s/ghi789/rust_in_kernel",
+		"submitter_user": "crab",
+		"tags": ["rust", "linux"]
+	}
+]`
+
+func TestLobstersRepository_Fetch_Hottest(t *testing.T) {
+	repo := &LobstersRepository{httpClient: newJSONMockClient(t, "https://lobste.rs/hottest.json", mockLobstersHottest)}
+	source := models.Source{Name: "Lobsters", Type: "lobsters", URL: "https://lobste.rs", Limit: 10}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(news) != 3 {
+		t.Fatalf("Expected 3 news items, got %d", len(news))
+	}
+
+	// Stories are sorted by score
+	expectedTitles := []string{"Rust in the kernel", "Go 1.23 is released", "What are you doing this week?"}
+	for i, title := range expectedTitles {
+		if news[i].Title != title {
+			t.Errorf("Expected item %d to be '%s', got '%s'", i, title, news[i].Title)
+		}
+	}
+
+	first := news[1]
+	if first.URL != "https://go.dev/blog/go1.23" || first.Score != 42 || first.Comments != 12 ||
+		first.Source != "Lobsters" || first.SubSource != "go, release" {
+		t.Errorf("Unexpected item: %+v", first)
+	}
+	if first.Description != "Score: 42, Comments: 12" {
+		t.Errorf("Expected the score description, got '%s'", first.Description)
+	}
+	if !first.PublishedAt.Equal(time.Date(2024, 8, 20, 14, 15, 0, 0, time.UTC)) {
+		t.Errorf("Expected published time 2024-08-20 14:15 UTC, got %v", first.PublishedAt)
+	}
+	if first.ID != "lobsters:abc123" || first.Author != "gopher" || first.Rank != 1 ||
+		first.DiscussionURL != "https://lobste.rs/s/abc123/go_1_23_is_released" {
+		t.Errorf("Unexpected item metadata: %+v", first)
+	}
+
+	// Text posts link to their discussion
+	text := news[2]
+	if text.URL != "https://lobste.rs/s/def456/what_are_you_doing_this_week" {
+		t.Errorf("Expected the comments URL for a text post, got %s", text.URL)
+	}
+	if text.Description != "Feel free to tell what you plan on doing this week & any help you need." {
+		t.Errorf("Expected the plain description, got '%s'", text.Description)
+	}
+}
+
+func TestLobstersRepository_Fetch_TagFilter(t *testing.T) {
+	testCases := []struct {
+		name           string
+		filter         models.PostFilter
+		expectedTitles []string
+	}{
+		{"include", models.PostFilter{IncludeTags: []string{"Go", "rust"}}, []string{"Rust in the kernel", "Go 1.23 is released"}},
+		{"exclude", models.PostFilter{ExcludeTags: []string{"ask", "linux"}}, []string{"Go 1.23 is released"}},
+		{"include and exclude", models.PostFilter{IncludeTags: []string{"go", "rust"}, ExcludeTags: []string{"linux"}}, []string{"Go 1.23 is released"}},
+	}
+
+	for _, tc := range testCases {
+		repo := &LobstersRepository{httpClient: newJSONMockClient(t, "https://lobste.rs/hottest.json", mockLobstersHottest)}
+		source := models.Source{Name: "Lobsters", Type: "lobsters", URL: "https://lobste.rs", Limit: 10, Filter: tc.filter}
+
+		news, err := repo.Fetch(context.Background(), source)
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tc.name, err)
+			continue
+		}
+		if len(news) != len(tc.expectedTitles) {
+			t.Errorf("%s: expected %d news items, got %d", tc.name, len(tc.expectedTitles), len(news))
+			continue
+		}
+		for i, title := range tc.expectedTitles {
+			if news[i].Title != title {
+				t.Errorf("%s: expected item %d to be '%s', got '%s'", tc.name, i, title, news[i].Title)
+			}
+		}
+	}
+}
+
+func TestLobstersRepository_Fetch_NewestKeepsOrder(t *testing.T) {
+	repo := &LobstersRepository{httpClient: newJSONMockClient(t, "https://lobste.rs/newest.json", mockLobstersHottest)}
+	source := models.Source{Name: "LobstersNew", Type: "lobsters", URL: "https://lobste.rs/", Limit: 2, Listing: "newest"}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(news) != 2 || news[0].Title != "Go 1.23 is released" || news[1].Title != "What are you doing this week?" {
+		t.Errorf("Expected the first 2 stories in listing order, got %+v", news)
+	}
+}
+
+func TestLobstersListingURL(t *testing.T) {
+	testCases := []struct {
+		source    models.Source
+		expected  string
+		expectErr bool
+	}{
+		{models.Source{}, "https://lobste.rs/hottest.json", false},
+		{models.Source{URL: "https://lobste.rs", Listing: "Newest"}, "https://lobste.rs/newest.json", false},
+		{models.Source{URL: "https://lobste.rs", SubSource: "go"}, "https://lobste.rs/t/go.json", false},
+		{models.Source{URL: "https://lobste.rs", SubSource: "Go, rust"}, "https://lobste.rs/t/go,rust.json", false},
+		{models.Source{URL: "https://lobste.rs", Listing: "active"}, "", true},
+		{models.Source{URL: "https://lobste.rs", Listing: "newest", SubSource: "go"}, "", true},
+	}
+
+	for _, tc := range testCases {
+		listingURL, _, err := lobstersListingURL(tc.source)
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("%+v: expected an error, got %s", tc.source, listingURL)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v: expected no error, got %v", tc.source, err)
+			continue
+		}
+		if listingURL != tc.expected {
+			t.Errorf("%+v: expected %s, got %s", tc.source, tc.expected, listingURL)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/utils"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+const (
+	// mastodonURL is the instance read when the source has no URL
+	mastodonURL = "https://mastodon.social"
+
+	// mastodonMaxLimit is the largest page of statuses the Mastodon API returns,
+	// the whole page is read so that the statuses can be ranked
+	mastodonMaxLimit = 40
+
+	// mastodonTitleLength is the maximum number of characters of a title taken from the status text
+	mastodonTitleLength = 100
+
+	// mastodonDescriptionLength is the maximum number of characters of a status description
+	mastodonDescriptionLength = 200
+)
+
+// MastodonRepository handles fetching the statuses of a hashtag or an account from a Mastodon instance
+type MastodonRepository struct {
+	httpClient http.HTTPClient
+}
+
+// mastodonStatus is a status of the Mastodon API
+type mastodonStatus struct {
+	ID              string `json:"id"`
+	CreatedAt       string `json:"created_at"`
+	URL             string `json:"url"`
+	URI             string `json:"uri"`
+	Content         string `json:"content"`
+	SpoilerText     string `json:"spoiler_text"`
+	Sensitive       bool   `json:"sensitive"`
+	Language        string `json:"language"`
+	RepliesCount    int    `json:"replies_count"`
+	ReblogsCount    int    `json:"reblogs_count"`
+	FavouritesCount int    `json:"favourites_count"`
+	Account         struct {
+		Acct        string `json:"acct"`
+		DisplayName string `json:"display_name"`
+	} `json:"account"`
+	Card *struct {
+		URL   string `json:"url"`
+		Title string `json:"title"`
+		Image string `json:"image"`
+	} `json:"card"`
+	Tags []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+}
+
+// mastodonAccount is an account returned by the account lookup
+type mastodonAccount struct {
+	ID string `json:"id"`
+}
+
+func init() {
+	Register("mastodon", func(httpClient http.HTTPClient) Fetcher {
+		return &MastodonRepository{
+			httpClient: httpClient,
+		}
+	})
+}
+
+// NewMastodonRepository creates a new MastodonRepository
+func NewMastodonRepository() *MastodonRepository {
+	return &MastodonRepository{
+		httpClient: http.NewClient(),
+	}
+}
+
+// Fetch fetches the most boosted and favourited statuses of the source sub-source published within
+// the source time window. The sub-source is a hashtag such as "#golang", or an account such as
+// "@golang" or "@golang@fosstodon.org".
+func (r *MastodonRepository) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	window, err := parseTimeWindow(source.TimeWindow)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported Mastodon time window: %w", err)
+	}
+
+	timelineURL, err := r.timelineURL(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []mastodonStatus
+	if err := r.httpClient.GetJSONContext(ctx, timelineURL, &statuses); err != nil {
+		return nil, fmt.Errorf("failed to fetch Mastodon statuses: %w", err)
+	}
+
+	fetchedAt := now()
+	var newsList []models.News
+	for i, status := range statuses {
+		if !mastodonStatusAllowed(status, source.Filter) {
+			continue
+		}
+
+		publishedAt, err := time.Parse(time.RFC3339, status.CreatedAt)
+		if err != nil {
+			fmt.Printf("WARNING: failed to parse Mastodon status date %s: %v\n", status.CreatedAt, err)
+			publishedAt = now() // Use current time as fallback
+		}
+		if window > 0 && publishedAt.Before(now().Add(-window)) {
+			continue
+		}
+
+		if news, ok := mastodonNews(status, publishedAt); ok {
+			news.Rank = i + 1
+			news.FetchedAt = fetchedAt
+			newsList = append(newsList, news)
+		}
+	}
+
+	// Sort by boosts and favourites, statuses with the same score keep the timeline order
+	sort.SliceStable(newsList, func(i, j int) bool {
+		return newsList[i].Score > newsList[j].Score
+	})
+
+	if source.Limit > 0 && len(newsList) > source.Limit {
+		newsList = newsList[:source.Limit]
+	}
+
+	return newsList, nil
+}
+
+// timelineURL returns the timeline request of a source, e.g. https://mastodon.social/api/v1/timelines/tag/golang?limit=40.
+// The id of an account is looked up first.
+func (r *MastodonRepository) timelineURL(ctx context.Context, source models.Source) (string, error) {
+	baseURL := strings.TrimSuffix(source.URL, "/")
+	if baseURL == "" {
+		baseURL = mastodonURL
+	}
+
+	subSource := strings.TrimSpace(source.SubSource)
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(mastodonMaxLimit))
+
+	acct, isAccount := strings.CutPrefix(subSource, "@")
+	if !isAccount {
+		hashtag := strings.TrimPrefix(subSource, "#")
+		if hashtag == "" {
+			return "", fmt.Errorf("hashtag or account is required for Mastodon source, e.g. #golang or @golang@fosstodon.org")
+		}
+		return fmt.Sprintf("%s/api/v1/timelines/tag/%s?%s", baseURL, url.PathEscape(hashtag), query.Encode()), nil
+	}
+
+	if acct == "" {
+		return "", fmt.Errorf("hashtag or account is required for Mastodon source, e.g. #golang or @golang@fosstodon.org")
+	}
+
+	var account mastodonAccount
+	lookupURL := fmt.Sprintf("%s/api/v1/accounts/lookup?acct=%s", baseURL, url.QueryEscape(acct))
+	if err := r.httpClient.GetJSONContext(ctx, lookupURL, &account); err != nil {
+		return "", fmt.Errorf("failed to look up Mastodon account %s: %w", acct, err)
+	}
+	if account.ID == "" {
+		return "", fmt.Errorf("mastodon account %s not found", acct)
+	}
+
+	// Replies and boosts of other statuses are not news of the account
+	query.Set("exclude_replies", "true")
+	query.Set("exclude_reblogs", "true")
+	return fmt.Sprintf("%s/api/v1/accounts/%s/statuses?%s", baseURL, url.PathEscape(account.ID), query.Encode()), nil
+}
+
+// mastodonStatusAllowed reports whether a status passes the filter, a content warning counts as a spoiler
+func mastodonStatusAllowed(status mastodonStatus, filter models.PostFilter) bool {
+	if filter.ExcludeNSFW && status.Sensitive {
+		return false
+	}
+	if filter.ExcludeSpoilers && status.SpoilerText != "" {
+		return false
+	}
+
+	var tags []string
+	for _, tag := range status.Tags {
+		tags = append(tags, tag.Name)
+	}
+	if len(filter.IncludeTags) > 0 && !tagsMatch(tags, filter.IncludeTags) {
+		return false
+	}
+	return !tagsMatch(tags, filter.ExcludeTags)
+}
+
+// mastodonNews converts a status into a news item, it reports false for a status without text or link.
+// A status sharing a link points to the linked page and is titled after it. A status with a content
+// warning is titled after the warning and points to the status, so that its text stays hidden.
+func mastodonNews(status mastodonStatus, publishedAt time.Time) (models.News, bool) {
+	text := utils.HTMLToText(status.Content)
+
+	title := utils.Truncate(text, mastodonTitleLength)
+	description := utils.Truncate(text, mastodonDescriptionLength)
+	discussionURL := firstNonEmpty(status.URL, status.URI)
+	statusURL := discussionURL
+	imageURL := ""
+	if spoiler := utils.HTMLToText(status.SpoilerText); spoiler != "" {
+		title = "CW: " + utils.Truncate(spoiler, mastodonTitleLength)
+		description = ""
+	} else if status.Card != nil && status.Card.URL != "" {
+		statusURL = status.Card.URL
+		imageURL = status.Card.Image
+		if cardTitle := utils.HTMLToText(status.Card.Title); cardTitle != "" {
+			title = cardTitle
+		}
+	}
+	if title == "" || statusURL == "" {
+		return models.News{}, false
+	}
+
+	if description == "" {
+		description = fmt.Sprintf("Boosts: %d, Favourites: %d", status.ReblogsCount, status.FavouritesCount)
+	}
+
+	var tags []string
+	for _, tag := range status.Tags {
+		tags = append(tags, tag.Name)
+	}
+
+	return models.News{
+		Title:         title,
+		URL:           statusURL,
+		Description:   description,
+		Source:        "Mastodon",
+		SubSource:     "@" + status.Account.Acct,
+		PublishedAt:   publishedAt,
+		Score:         status.ReblogsCount + status.FavouritesCount,
+		Comments:      status.RepliesCount,
+		ID:            "mastodon:" + firstNonEmpty(status.URI, status.URL),
+		Author:        status.Account.Acct,
+		Tags:          tags,
+		DiscussionURL: discussionURL,
+		ImageURL:      imageURL,
+		Language:      status.Language,
+	}, true
+}
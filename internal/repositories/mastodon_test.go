@@ -0,0 +1,212 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+)
+
+// mockMastodonStatuses is a #golang timeline: a status with a link card, a plain status, a content-warned
+// status and one older than the day window
+const mockMastodonStatuses = `[
+	{
+		"id": "1",
+		"created_at": "2024-08-20T09:00:00.000Z",
+		"url": "https://fosstodon.org/@golang/1",
+		"uri": "https://fosstodon.org/users/golang/statuses/1",
+		"content": "<p>Go 1.23 is out! Iterators &amp; more <a href=\"https://go.dev/blog/go1.23\">go.dev/blog/go1.23</a> <a href=\"https://fosstodon.org/tags/golang\">#<span>golang</span></a></p>",
+		"spoiler_text": "",
+		"sensitive": false,
+		"replies_count": 5,
+		"reblogs_count": 30,
+		"favourites_count": 50,
+		"account": {"acct": "golang@fosstodon.org", "display_name": "Go"},
+		"card": {"url": "https://go.dev/blog/go1.23", "title": "Go 1.23 is released"},
+		"tags": [{"name": "golang"}]
+	},
+	{
+		"id": "2",
+		"created_at": "2024-08-20T10:00:00.000Z",
+		"url": "https://mastodon.social/@gopher/2",
+		"uri": "https://mastodon.social/users/gopher/statuses/2",
+		"content": "<p>Just finished my first <a href=\"https://mastodon.social/tags/golang\">#golang</a> CLI tool.</p><p>Feedback welcome!</p>",
+		"spoiler_text": "",
+		"sensitive": false,
+		"replies_count": 2,
+		"reblogs_count": 1,
+		"favourites_count": 9,
+		"account": {"acct": "gopher", "display_name": "Gopher"},
+		"card": null,
+		"tags": [{"name": "golang"}, {"name": "cli"}]
+	},
+	{
+		"id": "3",
+		"created_at": "2024-08-20T11:00:00.000Z",
+		"url": "https://mastodon.social/@ranter/3",
+		"uri": "https://mastodon.social/users/ranter/statuses/3",
+		"content": "<p>Unpopular opinion about <a href=\"https://mastodon.social/tags/golang\">#golang</a> errors</p>",
+		"spoiler_text": "Hot take",
+		"sensitive": true,
+		"replies_count": 40,
+		"reblogs_count": 3,
+		"favourites_count": 4,
+		"account": {"acct": "ranter", "display_name": "Ranter"},
+		"card": null,
+		"tags": [{"name": "golang"}]
+	},
+	{
+		"id": "4",
+		"created_at": "2024-08-17T11:00:00.000Z",
+		"url": "https://mastodon.social/@gopher/4",
+		"uri": "https://mastodon.social/users/gopher/statuses/4",
+		"content": "<p>An old <a href=\"https://mastodon.social/tags/golang\">#golang</a> status</p>",
+		"spoiler_text": "",
+		"sensitive": false,
+		"replies_count": 0,
+		"reblogs_count": 100,
+		"favourites_count": 100,
+		"account": {"acct": "gopher", "display_name": "Gopher"},
+		"card": null,
+		"tags": [{"name": "golang"}]
+	}
+]`
+
+// newMastodonRepository returns a repository serving the recorded timeline at a fixed time
+func newMastodonRepository(t *testing.T, expectedURL string) *MastodonRepository {
+	mockClient := &MockHTTPClient{
+		GetJSONFunc: func(url string, v any) error {
+			switch url {
+			case "https://fosstodon.org/api/v1/accounts/lookup?acct=golang":
+				return json.Unmarshal([]byte(`{"id": "109", "acct": "golang"}`), v)
+			case expectedURL:
+				return json.Unmarshal([]byte(mockMastodonStatuses), v)
+			}
+			t.Fatalf("Unexpected URL: %s", url)
+			return nil
+		},
+	}
+	setTestNow(t, time.Date(2024, 8, 20, 12, 0, 0, 0, time.UTC))
+	return &MastodonRepository{httpClient: mockClient}
+}
+
+func TestMastodonRepository_Fetch_Hashtag(t *testing.T) {
+	repo := newMastodonRepository(t, "https://mastodon.social/api/v1/timelines/tag/golang?limit=40")
+	source := models.Source{Name: "MastodonGo", Type: "mastodon", SubSource: "#golang", Limit: 10}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Statuses are sorted by boosts and favourites, the status older than a day is dropped
+	if len(news) != 3 {
+		t.Fatalf("Expected 3 news items, got %d", len(news))
+	}
+
+	// A status with a card links to the card
+	card := news[0]
+	if card.Title != "Go 1.23 is released" || card.URL != "https://go.dev/blog/go1.23" || card.Score != 80 ||
+		card.Comments != 5 || card.Source != "Mastodon" || card.SubSource != "@golang@fosstodon.org" {
+		t.Errorf("Unexpected first item: %+v", card)
+	}
+	if card.Description != "Go 1.23 is out! Iterators & more go.dev/blog/go1.23 #golang" {
+		t.Errorf("Expected the status text, got '%s'", card.Description)
+	}
+	if !card.PublishedAt.Equal(time.Date(2024, 8, 20, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected published time 2024-08-20 09:00 UTC, got %v", card.PublishedAt)
+	}
+
+	// A status without a card links to itself and is titled after its text
+	text := news[1]
+	if text.Title != "Just finished my first #golang CLI tool. Feedback welcome!" || text.URL != "https://mastodon.social/@gopher/2" {
+		t.Errorf("Unexpected second item: %+v", text)
+	}
+
+	// The content warning replaces the text of a status
+	warned := news[2]
+	if warned.Title != "CW: Hot take" || warned.Description != "Boosts: 3, Favourites: 4" ||
+		warned.URL != "https://mastodon.social/@ranter/3" {
+		t.Errorf("Expected only the content warning to be published, got %+v", warned)
+	}
+}
+
+func TestMastodonNews_ContentWarningHidesCard(t *testing.T) {
+	var status mastodonStatus
+	if err := json.Unmarshal([]byte(`{
+		"url": "https://mastodon.social/@ranter/5",
+		"content": "<p>Spoilers for the keynote</p>",
+		"spoiler_text": "Conference spoilers",
+		"card": {"url": "https://example.com/keynote", "title": "The keynote announcement", "image": "https://example.com/keynote.png"}
+	}`), &status); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	news, ok := mastodonNews(status, time.Now())
+	if !ok {
+		t.Fatal("Expected a news item")
+	}
+	if news.Title != "CW: Conference spoilers" || news.URL != "https://mastodon.social/@ranter/5" || news.ImageURL != "" ||
+		strings.Contains(news.Description, "keynote") {
+		t.Errorf("Expected the card to stay hidden behind the content warning, got %+v", news)
+	}
+}
+
+func TestMastodonRepository_Fetch_Account(t *testing.T) {
+	repo := newMastodonRepository(t, "https://fosstodon.org/api/v1/accounts/109/statuses?exclude_reblogs=true&exclude_replies=true&limit=40")
+	source := models.Source{Name: "MastodonGo", Type: "mastodon", URL: "https://fosstodon.org/", SubSource: "@golang", Limit: 1, TimeWindow: "week"}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(news) != 1 || news[0].Title != "An old #golang status" {
+		t.Errorf("Expected the most boosted status of the week, got %+v", news)
+	}
+}
+
+func TestMastodonRepository_Fetch_Filter(t *testing.T) {
+	testCases := []struct {
+		name           string
+		filter         models.PostFilter
+		expectedTitles []string
+	}{
+		{"default", models.PostFilter{}, []string{"Go 1.23 is released", "Just finished my first #golang CLI tool. Feedback welcome!", "CW: Hot take"}},
+		{"exclude nsfw", models.PostFilter{ExcludeNSFW: true}, []string{"Go 1.23 is released", "Just finished my first #golang CLI tool. Feedback welcome!"}},
+		{"exclude spoilers", models.PostFilter{ExcludeSpoilers: true}, []string{"Go 1.23 is released", "Just finished my first #golang CLI tool. Feedback welcome!"}},
+		{"include tags", models.PostFilter{IncludeTags: []string{"CLI"}}, []string{"Just finished my first #golang CLI tool. Feedback welcome!"}},
+		{"exclude tags", models.PostFilter{ExcludeTags: []string{"cli"}}, []string{"Go 1.23 is released", "CW: Hot take"}},
+	}
+
+	for _, tc := range testCases {
+		repo := newMastodonRepository(t, "https://mastodon.social/api/v1/timelines/tag/golang?limit=40")
+		source := models.Source{Name: "MastodonGo", Type: "mastodon", SubSource: "golang", Limit: 10, Filter: tc.filter}
+
+		news, err := repo.Fetch(context.Background(), source)
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tc.name, err)
+			continue
+		}
+		if len(news) != len(tc.expectedTitles) {
+			t.Errorf("%s: expected %d news items, got %d", tc.name, len(tc.expectedTitles), len(news))
+			continue
+		}
+		for i, title := range tc.expectedTitles {
+			if news[i].Title != title {
+				t.Errorf("%s: expected item %d to be '%s', got '%s'", tc.name, i, title, news[i].Title)
+			}
+		}
+	}
+}
+
+func TestMastodonRepository_Fetch_MissingSubSource(t *testing.T) {
+	repo := newMastodonRepository(t, "")
+	for _, subSource := range []string{"", "#", "@"} {
+		if _, err := repo.Fetch(context.Background(), models.Source{SubSource: subSource}); err == nil {
+			t.Errorf("Expected an error for sub-source %q", subSource)
+		}
+	}
+}
@@ -0,0 +1,505 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/ducminhgd/gossip-bot/config"
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/utils"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+const (
+	// redditTokenURL is the endpoint issuing OAuth2 access tokens
+	redditTokenURL = "https://www.reddit.com/api/v1/access_token"
+
+	// redditOAuthURL is the base URL of the OAuth2 API
+	redditOAuthURL = "https://oauth.reddit.com"
+
+	// redditTokenExpiryMargin renews the access token slightly before Reddit expires it
+	redditTokenExpiryMargin = time.Minute
+
+	// redditDescriptionLength is the maximum number of characters of a post description
+	redditDescriptionLength = 100
+
+	// redditMaxLimit is the largest number of posts Reddit returns for a listing request
+	redditMaxLimit = 100
+
+	// redditFilterOverfetch is how many times the source limit is requested when a filter is set,
+	// so that filtered out posts do not leave the source short
+	redditFilterOverfetch = 3
+)
+
+var (
+	// redditListings are the supported listings, "hot" is used when none is configured
+	redditListings = []string{"hot", "top", "new", "rising"}
+
+	// redditTimeWindows are the supported time windows of the top listing, "day" is used when none is configured
+	redditTimeWindows = []string{"hour", "day", "week", "month", "year", "all"}
+)
+
+// RedditRepository handles fetching posts from Reddit
+// The OAuth2 access token is shared by all subreddits until it expires.
+type RedditRepository struct {
+	httpClient http.HTTPClient
+	appConfig  *config.RedditAppConfig
+
+	tokenMu     sync.Mutex
+	accessToken string
+	tokenExpiry time.Time
+}
+
+// redditListing is the response of a Reddit listing endpoint such as /r/{subreddit}/hot
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Data redditPost `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+// redditPost is a post of a Reddit listing
+type redditPost struct {
+	Name        string  `json:"name"`
+	Author      string  `json:"author"`
+	Thumbnail   string  `json:"thumbnail"`
+	Title       string  `json:"title"`
+	URL         string  `json:"url"`
+	Permalink   string  `json:"permalink"`
+	Score       int     `json:"score"`
+	NumComments int     `json:"num_comments"`
+	Created     float64 `json:"created_utc"`
+	Selftext    string  `json:"selftext"`
+	Subreddit   string  `json:"subreddit"`
+	Stickied    bool    `json:"stickied"`
+	Over18      bool    `json:"over_18"`
+	Spoiler     bool    `json:"spoiler"`
+	IsSelf      bool    `json:"is_self"`
+	Domain      string  `json:"domain"`
+	FlairText   string  `json:"link_flair_text"`
+}
+
+func init() {
+	Register("reddit", func(httpClient http.HTTPClient) Fetcher {
+		return newRedditRepository(httpClient)
+	})
+}
+
+// NewRedditRepository creates a new RedditRepository
+func NewRedditRepository() *RedditRepository {
+	return newRedditRepository(http.NewClient())
+}
+
+// newRedditRepository creates a RedditRepository with the given HTTP client.
+// If the Reddit app credentials are not configured, only unauthenticated requests are made.
+func newRedditRepository(httpClient http.HTTPClient) *RedditRepository {
+	appConfig, err := config.LoadRedditAppConfig()
+	if err != nil {
+		appConfig = &config.RedditAppConfig{
+			AppID:     "",
+			AppSecret: "",
+		}
+	}
+
+	return &RedditRepository{
+		httpClient: httpClient,
+		appConfig:  appConfig,
+	}
+}
+
+// Fetch fetches the posts of a subreddit listing using OAuth2 authentication,
+// falling back to an unauthenticated request if OAuth fails
+func (r *RedditRepository) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	subreddit, err := redditSubreddits(source.SubSource)
+	if err != nil {
+		return nil, err
+	}
+
+	listingPath, query, err := redditListingPath(subreddit, source)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get OAuth2 access token
+	accessToken, err := r.getRedditToken(ctx)
+	if err != nil {
+		// Fall back to unauthenticated request if OAuth fails
+		fmt.Printf("WARNING: failed to get Reddit OAuth token, falling back to unauthenticated request: %v\n", err)
+		return r.fetchUnauthenticated(ctx, source, listingPath, query, subreddit)
+	}
+
+	// Use OAuth2 API endpoint with the Bearer token
+	redditURL := fmt.Sprintf("%s%s?%s", redditOAuthURL, listingPath, query.Encode())
+	headers := map[string]string{
+		"Authorization": "Bearer " + accessToken,
+		"User-Agent":    r.userAgent(),
+	}
+
+	newsList, err := r.fetchListing(ctx, redditURL, headers, subreddit, source)
+	if err != nil {
+		// A rejected token is dropped so that the next source requests a new one
+		if statusErr, ok := http.AsStatusError(err); ok && statusErr.IsAuthError() {
+			r.invalidateToken()
+		}
+
+		// If OAuth request fails, fall back to unauthenticated request
+		fmt.Printf("WARNING: OAuth Reddit request failed, falling back to unauthenticated request: %v\n", err)
+		return r.fetchUnauthenticated(ctx, source, listingPath, query, subreddit)
+	}
+
+	return newsList, nil
+}
+
+// fetchUnauthenticated fetches the posts of a subreddit listing without authentication (fallback method)
+func (r *RedditRepository) fetchUnauthenticated(ctx context.Context, source models.Source, listingPath string, query url.Values, subreddit string) ([]models.News, error) {
+	redditURL := fmt.Sprintf("%s%s.json?%s", source.URL, listingPath, query.Encode())
+	headers := map[string]string{
+		"User-Agent": r.userAgent(),
+	}
+
+	return r.fetchListing(ctx, redditURL, headers, subreddit, source)
+}
+
+// fetchListing fetches and parses a Reddit listing
+func (r *RedditRepository) fetchListing(ctx context.Context, listingURL string, headers map[string]string, subreddit string, source models.Source) ([]models.News, error) {
+	body, err := r.httpClient.GetWithHeadersContext(ctx, listingURL, headers)
+	if err != nil {
+		if statusErr, ok := http.AsStatusError(err); ok {
+			switch {
+			case statusErr.IsRateLimited():
+				return nil, fmt.Errorf("reddit API rate limit exceeded: %w", err)
+			case statusErr.IsAuthError():
+				// If we get a 403 Forbidden error, it's likely due to Reddit's API restrictions
+				// This is common in CI/CD environments like GitHub Actions
+				return nil, fmt.Errorf("reddit API access forbidden (%d) - this is common in CI/CD environments: %w", statusErr.StatusCode, err)
+			case statusErr.IsNotFound():
+				return nil, fmt.Errorf("subreddit r/%s not found: %w", subreddit, err)
+			}
+		}
+		return nil, fmt.Errorf("failed to fetch Reddit data: %w", err)
+	}
+
+	return parseRedditListing(body, subreddit, source)
+}
+
+// redditSubreddits normalises a sub-source into the subreddit path segment.
+// Several subreddits, separated by "+" or ",", are combined into a multireddit such as "golang+rust".
+func redditSubreddits(subSource string) (string, error) {
+	names := strings.FieldsFunc(subSource, func(r rune) bool {
+		return r == '+' || r == ',' || unicode.IsSpace(r)
+	})
+
+	var subreddits []string
+	for _, name := range names {
+		name = strings.TrimPrefix(strings.TrimPrefix(name, "/"), "r/")
+		if name != "" {
+			subreddits = append(subreddits, url.PathEscape(name))
+		}
+	}
+
+	if len(subreddits) == 0 {
+		return "", fmt.Errorf("subreddit is required for Reddit source")
+	}
+
+	return strings.Join(subreddits, "+"), nil
+}
+
+// redditListingName returns the listing configured for a source, "hot" when none is configured
+func redditListingName(source models.Source) string {
+	if source.Listing == "" {
+		return "hot"
+	}
+	return strings.ToLower(source.Listing)
+}
+
+// redditListingPath returns the path and query of the listing configured for a source,
+// e.g. "/r/golang/top" and "limit=10&t=day"
+func redditListingPath(subreddit string, source models.Source) (string, url.Values, error) {
+	listing := redditListingName(source)
+	if !slices.Contains(redditListings, listing) {
+		return "", nil, fmt.Errorf("unsupported Reddit listing %q, expected one of %v", listing, redditListings)
+	}
+
+	// Request more posts when some may be filtered out, the listing is cut back to the limit after filtering
+	limit := source.Limit
+	if redditFilterActive(source.Filter) {
+		limit = min(limit*redditFilterOverfetch, redditMaxLimit)
+	}
+
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+
+	if listing == "top" {
+		timeWindow := strings.ToLower(source.TimeWindow)
+		if timeWindow == "" {
+			timeWindow = "day"
+		}
+		if !slices.Contains(redditTimeWindows, timeWindow) {
+			return "", nil, fmt.Errorf("unsupported Reddit time window %q, expected one of %v", timeWindow, redditTimeWindows)
+		}
+		query.Set("t", timeWindow)
+	} else if source.TimeWindow != "" {
+		return "", nil, fmt.Errorf("reddit time window is only supported by the top listing, got listing %q", listing)
+	}
+
+	return fmt.Sprintf("/r/%s/%s", subreddit, listing), query, nil
+}
+
+// parseRedditListing converts a Reddit listing response into news items in listing order,
+// dropping the posts rejected by the source filter and keeping at most source.Limit posts
+func parseRedditListing(body []byte, subreddit string, source models.Source) ([]models.News, error) {
+	var response redditListing
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse Reddit response: %w", err)
+	}
+
+	// Extract posts
+	var newsList []models.News
+	var skippedPosts []string
+
+	fetchedAt := time.Now()
+	for i, child := range response.Data.Children {
+		post := child.Data
+
+		// Skip stickied posts or announcements
+		if strings.HasPrefix(strings.ToLower(post.Title), "[announcement]") {
+			continue
+		}
+
+		// Skip posts with empty titles (shouldn't happen, but just in case)
+		if post.Title == "" {
+			fmt.Printf("WARNING: skipping Reddit post with empty title in r/%s\n", subreddit)
+			skippedPosts = append(skippedPosts, "unknown post")
+			continue
+		}
+
+		// Skip posts rejected by the source filter
+		if !redditPostAllowed(post, source.Filter) {
+			continue
+		}
+
+		// Keep the first posts of the listing once the limit is reached
+		if source.Limit > 0 && len(newsList) >= source.Limit {
+			break
+		}
+
+		// Create URL (use permalink if URL is empty)
+		postURL := post.URL
+		if postURL == "" || strings.HasPrefix(postURL, "/r/") {
+			postURL = fmt.Sprintf("https://www.reddit.com%s", post.Permalink)
+		}
+
+		// Create description, Reddit escapes HTML entities in the selftext
+		description := utils.Truncate(utils.HTMLToText(post.Selftext), redditDescriptionLength)
+		if description == "" {
+			description = fmt.Sprintf("Score: %d, Comments: %d", post.Score, post.NumComments)
+		}
+
+		// Posts of a multireddit are attributed to their own subreddit
+		postSubreddit := post.Subreddit
+		if postSubreddit == "" {
+			postSubreddit = subreddit
+		}
+
+		// Thumbnail is "self", "default" or "nsfw" for posts without a preview image
+		imageURL := ""
+		if strings.HasPrefix(post.Thumbnail, "http") {
+			imageURL = post.Thumbnail
+		}
+
+		var tags []string
+		if post.FlairText != "" {
+			tags = []string{post.FlairText}
+		}
+
+		// Create news item
+		news := models.News{
+			Title:         post.Title,
+			URL:           postURL,
+			Description:   description,
+			Source:        "Reddit",
+			SubSource:     postSubreddit,
+			PublishedAt:   time.Unix(int64(post.Created), 0),
+			Score:         post.Score,
+			Comments:      post.NumComments,
+			ID:            "reddit:" + firstNonEmpty(post.Name, post.Permalink),
+			Author:        post.Author,
+			Tags:          tags,
+			Rank:          i + 1,
+			DiscussionURL: fmt.Sprintf("https://www.reddit.com%s", post.Permalink),
+			ImageURL:      imageURL,
+			FetchedAt:     fetchedAt,
+		}
+
+		newsList = append(newsList, news)
+	}
+
+	// If all posts were skipped, return an error
+	if len(newsList) == 0 && len(skippedPosts) > 0 {
+		return nil, fmt.Errorf("failed to fetch any Reddit posts from r/%s, skipped: %v", subreddit, skippedPosts)
+	}
+
+	// The hot, new and rising listings keep Reddit's order, a multireddit top listing is merged by score
+	if redditListingName(source) == "top" {
+		sort.SliceStable(newsList, func(i, j int) bool {
+			return newsList[i].Score > newsList[j].Score
+		})
+	}
+
+	return newsList, nil
+}
+
+// redditFilterActive reports whether the filter can drop any post
+func redditFilterActive(filter models.PostFilter) bool {
+	return filter.ExcludeStickied || filter.ExcludeNSFW || filter.ExcludeSpoilers ||
+		(filter.SelfPosts != "" && filter.SelfPosts != "include") ||
+		len(filter.IncludeFlairs) > 0 || len(filter.ExcludeFlairs) > 0 ||
+		len(filter.IncludeDomains) > 0 || len(filter.ExcludeDomains) > 0
+}
+
+// redditPostAllowed reports whether a post passes the filter
+func redditPostAllowed(post redditPost, filter models.PostFilter) bool {
+	if (filter.ExcludeStickied && post.Stickied) ||
+		(filter.ExcludeNSFW && post.Over18) ||
+		(filter.ExcludeSpoilers && post.Spoiler) {
+		return false
+	}
+
+	switch filter.SelfPosts {
+	case "exclude":
+		if post.IsSelf {
+			return false
+		}
+	case "only":
+		if !post.IsSelf {
+			return false
+		}
+	}
+
+	if len(filter.IncludeFlairs) > 0 && !redditFlairMatches(post.FlairText, filter.IncludeFlairs) {
+		return false
+	}
+	if redditFlairMatches(post.FlairText, filter.ExcludeFlairs) {
+		return false
+	}
+
+	// Self posts have a "self.{subreddit}" domain, so domain rules only apply to link posts
+	if !post.IsSelf {
+		if len(filter.IncludeDomains) > 0 && !redditDomainMatches(post.Domain, filter.IncludeDomains) {
+			return false
+		}
+		if redditDomainMatches(post.Domain, filter.ExcludeDomains) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// redditFlairMatches reports whether the flair equals one of the given flairs, ignoring case
+func redditFlairMatches(flair string, flairs []string) bool {
+	flair = strings.TrimSpace(flair)
+	if flair == "" {
+		return false
+	}
+	for _, f := range flairs {
+		if strings.EqualFold(flair, strings.TrimSpace(f)) {
+			return true
+		}
+	}
+	return false
+}
+
+// redditDomainMatches reports whether the domain is one of the given domains or a subdomain of one
+func redditDomainMatches(domain string, domains []string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "www."))
+	if domain == "" {
+		return false
+	}
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "www."))
+		if domain == d || strings.HasSuffix(domain, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// getRedditToken returns an OAuth2 access token from Reddit API,
+// reusing the cached token until it expires
+func (r *RedditRepository) getRedditToken(ctx context.Context) (string, error) {
+	// Check if we have app credentials
+	if r.appConfig.AppID == "" || r.appConfig.AppSecret == "" {
+		return "", fmt.Errorf("reddit app credentials not configured")
+	}
+
+	// Holding the lock while requesting makes concurrent sources share a single token request
+	r.tokenMu.Lock()
+	defer r.tokenMu.Unlock()
+
+	if r.accessToken != "" && now().Before(r.tokenExpiry) {
+		return r.accessToken, nil
+	}
+
+	// Prepare form data for OAuth2 client credentials grant
+	data := url.Values{}
+	data.Set("grant_type", "client_credentials")
+
+	// Create basic auth header
+	auth := base64.StdEncoding.EncodeToString([]byte(r.appConfig.AppID + ":" + r.appConfig.AppSecret))
+	headers := map[string]string{
+		"Authorization": "Basic " + auth,
+		"User-Agent":    r.userAgent(),
+	}
+
+	// Make POST request to get access token
+	body, err := r.httpClient.PostFormContext(ctx, redditTokenURL, data, headers)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Reddit access token: %w", err)
+	}
+
+	// Parse response
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+		Scope       string `json:"scope"`
+	}
+
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return "", fmt.Errorf("failed to parse Reddit token response: %w", err)
+	}
+
+	if tokenResponse.AccessToken == "" {
+		return "", fmt.Errorf("no access token received from Reddit API")
+	}
+
+	r.accessToken = tokenResponse.AccessToken
+	r.tokenExpiry = now().Add(time.Duration(tokenResponse.ExpiresIn)*time.Second - redditTokenExpiryMargin)
+
+	return r.accessToken, nil
+}
+
+// invalidateToken drops the cached access token
+func (r *RedditRepository) invalidateToken() {
+	r.tokenMu.Lock()
+	defer r.tokenMu.Unlock()
+	r.accessToken = ""
+	r.tokenExpiry = time.Time{}
+}
+
+// userAgent returns the User-Agent Reddit requires for API clients
+func (r *RedditRepository) userAgent() string {
+	return "github:" + r.appConfig.AppID + ":v0.1 by u/ducminhgd"
+}
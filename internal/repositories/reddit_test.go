@@ -0,0 +1,452 @@
+package repositories
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/config"
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+// mockRedditListing is an r/golang listing with an [Announcement] thread, a link post and a self post
+const mockRedditListing = `{
+	"kind": "Listing",
+	"data": {
+		"children": [
+			{"kind": "t3", "data": {
+				"title": "[Announcement] Weekly Go jobs thread",
+				"url": "https://www.reddit.com/r/golang/comments/1/weekly/",
+				"permalink": "/r/golang/comments/1/weekly/",
+				"score": 5000, "num_comments": 300, "created_utc": 1625097600.0, "selftext": "Post jobs here"
+			}},
+			{"kind": "t3", "data": {
+				"title": "Go 1.23 released",
+				"url": "https://go.dev/blog/go1.23",
+				"permalink": "/r/golang/comments/2/go_123_released/",
+				"score": 100, "num_comments": 10, "created_utc": 1625184000.0, "selftext": ""
+			}},
+			{"kind": "t3", "data": {
+				"name": "t3_3", "author": "gopher", "thumbnail": "self", "link_flair_text": "discussion",
+				"title": "How do you structure your projects?",
+				"url": "/r/golang/comments/3/how_do_you_structure/",
+				"permalink": "/r/golang/comments/3/how_do_you_structure/",
+				"score": 200, "num_comments": 20, "created_utc": 1625270400.0, "selftext": "I keep going back and forth between a flat layout and cmd/internal/pkg, what do you use and why does it work for you?"
+			}}
+		]
+	}
+}`
+
+// newTestRedditRepository creates a RedditRepository with a mock client and app credentials
+func newTestRedditRepository(mockClient *MockHTTPClient) *RedditRepository {
+	return &RedditRepository{
+		httpClient: mockClient,
+		appConfig:  &config.RedditAppConfig{AppID: "app", AppSecret: "secret"},
+	}
+}
+
+func TestRedditRepository_Fetch_OAuth(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		PostFormFunc: func(url string, data url.Values, headers map[string]string) ([]byte, error) {
+			if url != redditTokenURL {
+				t.Fatalf("Unexpected URL: %s", url)
+			}
+			if data.Get("grant_type") != "client_credentials" {
+				t.Errorf("Expected client_credentials grant, got %s", data.Get("grant_type"))
+			}
+			return []byte(`{"access_token": "token-1", "token_type": "bearer", "expires_in": 86400}`), nil
+		},
+		GetWithHeadersFunc: func(url string, headers map[string]string) ([]byte, error) {
+			if url != "https://oauth.reddit.com/r/golang/hot?limit=3" {
+				t.Fatalf("Unexpected URL: %s", url)
+			}
+			if headers["Authorization"] != "Bearer token-1" {
+				t.Errorf("Expected bearer token, got '%s'", headers["Authorization"])
+			}
+			return []byte(mockRedditListing), nil
+		},
+	}
+
+	repo := newTestRedditRepository(mockClient)
+	source := models.Source{Name: "RedditGo", Type: "reddit", URL: "https://www.reddit.com", Limit: 3, SubSource: "golang"}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The announcement is skipped and the hot listing keeps Reddit's order
+	if len(news) != 2 {
+		t.Fatalf("Expected 2 news items, got %d", len(news))
+	}
+
+	expectedSelfPost := models.News{
+		Title:         "How do you structure your projects?",
+		URL:           "https://www.reddit.com/r/golang/comments/3/how_do_you_structure/",
+		Description:   "I keep going back and forth between a flat layout and cmd/internal/pkg, what do you use and why does...",
+		Source:        "Reddit",
+		SubSource:     "golang",
+		PublishedAt:   time.Unix(1625270400, 0),
+		Score:         200,
+		Comments:      20,
+		ID:            "reddit:t3_3",
+		Author:        "gopher",
+		Tags:          []string{"discussion"},
+		Rank:          3,
+		DiscussionURL: "https://www.reddit.com/r/golang/comments/3/how_do_you_structure/",
+	}
+
+	if news[0].URL != "https://go.dev/blog/go1.23" {
+		t.Errorf("Expected link post URL, got %s", news[0].URL)
+	}
+	if news[0].Description != "Score: 100, Comments: 10" {
+		t.Errorf("Expected score description, got '%s'", news[0].Description)
+	}
+
+	// The fetch time is the time of the test run
+	if news[1].FetchedAt.IsZero() {
+		t.Error("Expected the fetch time to be set")
+	}
+	news[1].FetchedAt = time.Time{}
+	if !reflect.DeepEqual(news[1], expectedSelfPost) {
+		t.Errorf("Expected %+v, got %+v", expectedSelfPost, news[1])
+	}
+}
+
+func TestRedditRepository_TokenCaching(t *testing.T) {
+	now := time.Date(2025, 7, 4, 0, 0, 0, 0, time.UTC)
+	tokenRequests := 0
+
+	mockClient := &MockHTTPClient{
+		PostFormFunc: func(url string, data url.Values, headers map[string]string) ([]byte, error) {
+			tokenRequests++
+			return []byte(`{"access_token": "token", "token_type": "bearer", "expires_in": 3600}`), nil
+		},
+		GetWithHeadersFunc: func(url string, headers map[string]string) ([]byte, error) {
+			return []byte(mockRedditListing), nil
+		},
+	}
+
+	setTestNow(t, now)
+	repo := newTestRedditRepository(mockClient)
+
+	for _, subreddit := range []string{"golang", "python", "database"} {
+		source := models.Source{Name: subreddit, Type: "reddit", URL: "https://www.reddit.com", Limit: 3, SubSource: subreddit}
+		if _, err := repo.Fetch(context.Background(), source); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Fatalf("Expected the token to be requested once, got %d", tokenRequests)
+	}
+
+	// Once the token is about to expire, a new one is requested
+	now = now.Add(time.Hour - redditTokenExpiryMargin)
+	setTestNow(t, now)
+	if _, err := repo.getRedditToken(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tokenRequests != 2 {
+		t.Fatalf("Expected the token to be renewed, got %d token requests", tokenRequests)
+	}
+}
+
+func TestRedditRepository_FallbackToUnauthenticated(t *testing.T) {
+	var requestedURLs []string
+
+	mockClient := &MockHTTPClient{
+		PostFormFunc: func(url string, data url.Values, headers map[string]string) ([]byte, error) {
+			return []byte(`{"access_token": "token", "token_type": "bearer", "expires_in": 3600}`), nil
+		},
+		GetWithHeadersFunc: func(url string, headers map[string]string) ([]byte, error) {
+			requestedURLs = append(requestedURLs, url)
+			if strings.HasPrefix(url, redditOAuthURL) {
+				return nil, &http.StatusError{StatusCode: 401, URL: url}
+			}
+			if _, ok := headers["Authorization"]; ok {
+				t.Error("Expected no Authorization header for unauthenticated requests")
+			}
+			return []byte(mockRedditListing), nil
+		},
+	}
+
+	repo := newTestRedditRepository(mockClient)
+	source := models.Source{Name: "RedditGo", Type: "reddit", URL: "https://www.reddit.com", Limit: 3, SubSource: "golang"}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(news) != 2 {
+		t.Fatalf("Expected 2 news items, got %d", len(news))
+	}
+
+	expectedURLs := []string{
+		"https://oauth.reddit.com/r/golang/hot?limit=3",
+		"https://www.reddit.com/r/golang/hot.json?limit=3",
+	}
+	if !reflect.DeepEqual(requestedURLs, expectedURLs) {
+		t.Errorf("Expected requests %v, got %v", expectedURLs, requestedURLs)
+	}
+
+	// The rejected token is not reused
+	if repo.accessToken != "" {
+		t.Error("Expected the rejected token to be dropped")
+	}
+}
+
+func TestRedditRepository_Fetch_Forbidden(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		GetWithHeadersFunc: func(url string, headers map[string]string) ([]byte, error) {
+			return nil, &http.StatusError{StatusCode: 403, URL: url}
+		},
+	}
+
+	// Without credentials, only the unauthenticated endpoint is used
+	repo := &RedditRepository{
+		httpClient: mockClient,
+		appConfig:  &config.RedditAppConfig{},
+	}
+	source := models.Source{Name: "RedditGo", Type: "reddit", URL: "https://www.reddit.com", Limit: 3, SubSource: "golang"}
+
+	_, err := repo.Fetch(context.Background(), source)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "reddit API access forbidden (403)") {
+		t.Errorf("Expected forbidden error, got '%s'", err.Error())
+	}
+	if _, ok := http.AsStatusError(err); !ok {
+		t.Error("Expected the status error to be wrapped")
+	}
+}
+
+func TestRedditRepository_Fetch_MissingSubreddit(t *testing.T) {
+	repo := newTestRedditRepository(&MockHTTPClient{})
+
+	_, err := repo.Fetch(context.Background(), models.Source{Name: "Reddit", Type: "reddit"})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+}
+
+func TestParseRedditListing_InvalidJSON(t *testing.T) {
+	_, err := parseRedditListing([]byte("not json"), "golang", models.Source{})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "failed to parse Reddit response") {
+		t.Errorf("Expected parse error, got '%s'", err.Error())
+	}
+}
+
+func TestRedditListingPath(t *testing.T) {
+	testCases := []struct {
+		subSource    string
+		listing      string
+		timeWindow   string
+		expectedPath string
+		expectedQS   string
+		expectErr    bool
+	}{
+		{"golang", "", "", "/r/golang/hot", "limit=10", false},
+		{"golang", "new", "", "/r/golang/new", "limit=10", false},
+		{"golang", "rising", "", "/r/golang/rising", "limit=10", false},
+		{"golang", "top", "", "/r/golang/top", "limit=10&t=day", false},
+		{"golang", "Top", "week", "/r/golang/top", "limit=10&t=week", false},
+		{"golang+rust", "top", "hour", "/r/golang+rust/top", "limit=10&t=hour", false},
+		{"golang, rust", "", "", "/r/golang+rust/hot", "limit=10", false},
+		{"r/golang", "", "", "/r/golang/hot", "limit=10", false},
+		{"golang", "controversial", "", "", "", true},
+		{"golang", "top", "decade", "", "", true},
+		{"golang", "hot", "day", "", "", true},
+		{" + ", "", "", "", "", true},
+	}
+
+	for _, tc := range testCases {
+		source := models.Source{SubSource: tc.subSource, Listing: tc.listing, TimeWindow: tc.timeWindow, Limit: 10}
+
+		subreddit, err := redditSubreddits(source.SubSource)
+		var path string
+		var query url.Values
+		if err == nil {
+			path, query, err = redditListingPath(subreddit, source)
+		}
+
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("%q/%q/%q: expected an error, got %s", tc.subSource, tc.listing, tc.timeWindow, path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q/%q/%q: expected no error, got %v", tc.subSource, tc.listing, tc.timeWindow, err)
+			continue
+		}
+		if path != tc.expectedPath || query.Encode() != tc.expectedQS {
+			t.Errorf("%q/%q/%q: expected %s?%s, got %s?%s", tc.subSource, tc.listing, tc.timeWindow, tc.expectedPath, tc.expectedQS, path, query.Encode())
+		}
+	}
+}
+
+func TestRedditRepository_Fetch_MultiredditTopOfTheDay(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		GetWithHeadersFunc: func(url string, headers map[string]string) ([]byte, error) {
+			if url != "https://www.reddit.com/r/golang+rust/top.json?limit=2&t=day" {
+				t.Fatalf("Unexpected URL: %s", url)
+			}
+			return []byte(`{"data": {"children": [
+				{"data": {"title": "Go post", "url": "https://go.dev", "score": 10, "subreddit": "golang"}},
+				{"data": {"title": "Rust post", "url": "https://rust-lang.org", "score": 20, "subreddit": "rust"}}
+			]}}`), nil
+		},
+	}
+
+	repo := &RedditRepository{
+		httpClient: mockClient,
+		appConfig:  &config.RedditAppConfig{},
+	}
+	source := models.Source{
+		Name:       "RedditSystems",
+		Type:       "reddit",
+		URL:        "https://www.reddit.com",
+		Limit:      2,
+		SubSource:  "golang+rust",
+		Listing:    "top",
+		TimeWindow: "day",
+	}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(news) != 2 {
+		t.Fatalf("Expected 2 news items, got %d", len(news))
+	}
+
+	// Each post keeps the subreddit it was posted in
+	if news[0].SubSource != "rust" || news[1].SubSource != "golang" {
+		t.Errorf("Expected sub-sources [rust golang], got [%s %s]", news[0].SubSource, news[1].SubSource)
+	}
+}
+
+func TestRedditRepository_Fetch_NewKeepsOrder(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		GetWithHeadersFunc: func(url string, headers map[string]string) ([]byte, error) {
+			if url != "https://www.reddit.com/r/golang/new.json?limit=3" {
+				t.Fatalf("Unexpected URL: %s", url)
+			}
+			return []byte(`{"data": {"children": [
+				{"data": {"title": "Newest", "url": "https://example.com/3", "score": 1, "created_utc": 1625270400.0}},
+				{"data": {"title": "Older", "url": "https://example.com/2", "score": 30, "created_utc": 1625184000.0}},
+				{"data": {"title": "Oldest", "url": "https://example.com/1", "score": 7, "created_utc": 1625097600.0}}
+			]}}`), nil
+		},
+	}
+
+	repo := &RedditRepository{
+		httpClient: mockClient,
+		appConfig:  &config.RedditAppConfig{},
+	}
+	source := models.Source{Name: "RedditGoNew", Type: "reddit", URL: "https://www.reddit.com", Limit: 3, SubSource: "golang", Listing: "new"}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var titles []string
+	for _, item := range news {
+		titles = append(titles, item.Title)
+	}
+	if expected := []string{"Newest", "Older", "Oldest"}; !reflect.DeepEqual(titles, expected) {
+		t.Errorf("Expected the newest posts first %v, got %v", expected, titles)
+	}
+}
+
+func TestRedditRepository_Fetch_Filter(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		GetWithHeadersFunc: func(url string, headers map[string]string) ([]byte, error) {
+			// The limit is raised so that filtered out posts do not leave the section short
+			if url != "https://www.reddit.com/r/golang/hot.json?limit=6" {
+				t.Fatalf("Unexpected URL: %s", url)
+			}
+			return []byte(`{"data": {"children": [
+				{"data": {"title": "Weekly questions", "stickied": true, "is_self": true, "domain": "self.golang", "score": 50}},
+				{"data": {"title": "NSFW post", "over_18": true, "url": "https://example.com/a", "domain": "example.com", "score": 40}},
+				{"data": {"title": "Meme", "link_flair_text": "Meme", "url": "https://i.redd.it/x.png", "domain": "i.redd.it", "score": 30}},
+				{"data": {"title": "Go blog", "link_flair_text": "news", "url": "https://go.dev/blog/x", "domain": "go.dev", "score": 20}},
+				{"data": {"title": "Medium post", "url": "https://medium.com/x", "domain": "blog.medium.com", "score": 15}},
+				{"data": {"title": "Discussion", "is_self": true, "domain": "self.golang", "permalink": "/r/golang/comments/9/d/", "score": 10}},
+				{"data": {"title": "GitHub repo", "url": "https://github.com/x/y", "domain": "github.com", "score": 5}}
+			]}}`), nil
+		},
+	}
+
+	repo := &RedditRepository{
+		httpClient: mockClient,
+		appConfig:  &config.RedditAppConfig{},
+	}
+	source := models.Source{
+		Name:      "RedditGo",
+		Type:      "reddit",
+		URL:       "https://www.reddit.com",
+		Limit:     2,
+		SubSource: "golang",
+		Filter: models.PostFilter{
+			ExcludeStickied: true,
+			ExcludeNSFW:     true,
+			ExcludeFlairs:   []string{"meme"},
+			ExcludeDomains:  []string{"medium.com"},
+		},
+	}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The first two posts passing the filter are kept
+	if len(news) != 2 {
+		t.Fatalf("Expected 2 news items, got %d", len(news))
+	}
+	if news[0].Title != "Go blog" || news[1].Title != "Discussion" {
+		t.Errorf("Expected [Go blog Discussion], got [%s %s]", news[0].Title, news[1].Title)
+	}
+}
+
+func TestRedditPostAllowed(t *testing.T) {
+	link := redditPost{Title: "Link", Domain: "blog.golang.org", FlairText: "News"}
+	self := redditPost{Title: "Self", IsSelf: true, Domain: "self.golang", FlairText: "Discussion"}
+
+	testCases := []struct {
+		name     string
+		post     redditPost
+		filter   models.PostFilter
+		expected bool
+	}{
+		{"no filter", link, models.PostFilter{}, true},
+		{"spoiler", redditPost{Spoiler: true}, models.PostFilter{ExcludeSpoilers: true}, false},
+		{"self posts excluded", self, models.PostFilter{SelfPosts: "exclude"}, false},
+		{"self posts only keeps self", self, models.PostFilter{SelfPosts: "only"}, true},
+		{"self posts only drops links", link, models.PostFilter{SelfPosts: "only"}, false},
+		{"include flair ignores case", link, models.PostFilter{IncludeFlairs: []string{"news"}}, true},
+		{"include flair drops others", self, models.PostFilter{IncludeFlairs: []string{"news"}}, false},
+		{"exclude flair", self, models.PostFilter{ExcludeFlairs: []string{"Discussion"}}, false},
+		{"include domain matches subdomain", link, models.PostFilter{IncludeDomains: []string{"golang.org"}}, true},
+		{"include domain drops others", link, models.PostFilter{IncludeDomains: []string{"github.com"}}, false},
+		{"domain rules ignore self posts", self, models.PostFilter{IncludeDomains: []string{"github.com"}}, true},
+		{"exclude domain", link, models.PostFilter{ExcludeDomains: []string{"www.golang.org"}}, false},
+		{"domain suffix is not a subdomain", link, models.PostFilter{ExcludeDomains: []string{"lang.org"}}, true},
+	}
+
+	for _, tc := range testCases {
+		if got := redditPostAllowed(tc.post, tc.filter); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
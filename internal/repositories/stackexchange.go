@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/utils"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+const (
+	// stackExchangeURL is the base URL of the Stack Exchange API, used when the source has no URL
+	stackExchangeURL = "https://api.stackexchange.com/2.3"
+
+	// stackExchangeMaxPageSize is the largest page the Stack Exchange API returns
+	stackExchangeMaxPageSize = 100
+
+	// stackExchangeLowQuota is the remaining daily quota below which a warning is logged
+	stackExchangeLowQuota = 50
+)
+
+// stackExchangeListings are the supported question sorts, "hot" is used when none is configured
+var stackExchangeListings = []string{"hot", "votes", "activity"}
+
+// StackExchangeRepository handles fetching questions from a Stack Exchange site such as Stack Overflow
+type StackExchangeRepository struct {
+	httpClient http.HTTPClient
+}
+
+// stackExchangeResponse is the response wrapper of the Stack Exchange API
+type stackExchangeResponse struct {
+	Items          []stackExchangeQuestion `json:"items"`
+	QuotaRemaining int                     `json:"quota_remaining"`
+
+	// Backoff is the number of seconds to wait before calling the same method again
+	Backoff int `json:"backoff"`
+}
+
+// stackExchangeQuestion is a question returned by the /questions method
+type stackExchangeQuestion struct {
+	QuestionID   int      `json:"question_id"`
+	Title        string   `json:"title"`
+	Link         string   `json:"link"`
+	Score        int      `json:"score"`
+	AnswerCount  int      `json:"answer_count"`
+	ViewCount    int      `json:"view_count"`
+	IsAnswered   bool     `json:"is_answered"`
+	CreationDate int64    `json:"creation_date"`
+	Tags         []string `json:"tags"`
+	Owner        struct {
+		DisplayName string `json:"display_name"`
+	} `json:"owner"`
+}
+
+func init() {
+	Register("stackexchange", func(httpClient http.HTTPClient) Fetcher {
+		return &StackExchangeRepository{
+			httpClient: httpClient,
+		}
+	})
+}
+
+// NewStackExchangeRepository creates a new StackExchangeRepository
+func NewStackExchangeRepository() *StackExchangeRepository {
+	return &StackExchangeRepository{
+		httpClient: http.NewClient(),
+	}
+}
+
+// Fetch fetches the questions of the site given by the source sub-source, such as "stackoverflow",
+// tagged with all of the source tags and sorted by the source listing
+func (r *StackExchangeRepository) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	questionsURL, err := r.questionsURL(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var response stackExchangeResponse
+	if err := r.httpClient.GetJSONContext(ctx, questionsURL, &response); err != nil {
+		if statusErr, ok := http.AsStatusError(err); ok && statusErr.StatusCode == 400 && strings.Contains(statusErr.Body, "throttle_violation") {
+			return nil, fmt.Errorf("stack exchange API quota exceeded: %w", err)
+		}
+		return nil, fmt.Errorf("failed to fetch Stack Exchange questions: %w", err)
+	}
+
+	// The API asks clients to back off from a method for a while when it is under load,
+	// so the following requests to the API wait for it
+	if response.Backoff > 0 {
+		if pauser, ok := r.httpClient.(http.HostPauser); ok {
+			if parsed, err := url.Parse(questionsURL); err == nil {
+				pauser.PauseHost(parsed.Host, now().Add(time.Duration(response.Backoff)*time.Second))
+			}
+		}
+	}
+	if response.QuotaRemaining > 0 && response.QuotaRemaining < stackExchangeLowQuota {
+		fmt.Printf("WARNING: Stack Exchange API quota is running low, %d requests left today\n", response.QuotaRemaining)
+	}
+
+	site := strings.TrimSpace(source.SubSource)
+	fetchedAt := now()
+	var newsList []models.News
+	for i, question := range response.Items {
+		// Titles are HTML-escaped
+		title := utils.HTMLToText(question.Title)
+		if title == "" {
+			continue
+		}
+
+		newsList = append(newsList, models.News{
+			Title:         title,
+			URL:           question.Link,
+			Description:   fmt.Sprintf("Score: %d, Answers: %d, Views: %d", question.Score, question.AnswerCount, question.ViewCount),
+			Source:        "Stack Exchange",
+			SubSource:     strings.Join(question.Tags, ", "),
+			PublishedAt:   time.Unix(question.CreationDate, 0),
+			Score:         question.Score,
+			Comments:      question.AnswerCount,
+			ID:            fmt.Sprintf("stackexchange:%s:%d", site, question.QuestionID),
+			Author:        utils.HTMLToText(question.Owner.DisplayName),
+			Tags:          question.Tags,
+			Rank:          i + 1,
+			DiscussionURL: question.Link,
+			FetchedAt:     fetchedAt,
+		})
+	}
+
+	if source.Limit > 0 && len(newsList) > source.Limit {
+		newsList = newsList[:source.Limit]
+	}
+
+	return newsList, nil
+}
+
+// questionsURL returns the /questions request of a source, e.g.
+// https://api.stackexchange.com/2.3/questions?order=desc&pagesize=10&site=stackoverflow&sort=hot&tagged=go;postgresql
+func (r *StackExchangeRepository) questionsURL(source models.Source) (string, error) {
+	site := strings.TrimSpace(source.SubSource)
+	if site == "" {
+		return "", fmt.Errorf("site is required for Stack Exchange source, e.g. stackoverflow")
+	}
+
+	listing := strings.ToLower(source.Listing)
+	if listing == "" {
+		listing = "hot"
+	}
+	if !slices.Contains(stackExchangeListings, listing) {
+		return "", fmt.Errorf("unsupported Stack Exchange listing %q, expected one of %v", listing, stackExchangeListings)
+	}
+
+	baseURL := strings.TrimSuffix(source.URL, "/")
+	if baseURL == "" {
+		baseURL = stackExchangeURL
+	}
+
+	query := url.Values{}
+	query.Set("site", site)
+	query.Set("sort", listing)
+	query.Set("order", "desc")
+	query.Set("pagesize", strconv.Itoa(min(max(source.Limit, 1), stackExchangeMaxPageSize)))
+
+	// Tags may be given as "go;postgresql" like on the site, or as a comma-separated list
+	var tags []string
+	for _, tag := range source.Tags {
+		tags = append(tags, trimmedStrings(strings.Split(tag, ";"))...)
+	}
+	if len(tags) > 0 {
+		query.Set("tagged", strings.Join(tags, ";"))
+	}
+
+	// Hot questions are recent by definition, other sorts are limited to the questions of the time window
+	if listing != "hot" {
+		window, err := parseTimeWindow(source.TimeWindow)
+		if err != nil {
+			return "", fmt.Errorf("unsupported Stack Exchange time window: %w", err)
+		}
+		if window > 0 {
+			query.Set("fromdate", strconv.FormatInt(now().Add(-window).Unix(), 10))
+		}
+	} else if source.TimeWindow != "" {
+		return "", fmt.Errorf("stack exchange time window is not supported by the hot listing")
+	}
+
+	return fmt.Sprintf("%s/questions?%s", baseURL, query.Encode()), nil
+}
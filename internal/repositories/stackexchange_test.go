@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+// mockStackExchangeQuestions holds an answered and an unanswered question with HTML escaped titles,
+// and asks for a backoff of 10 seconds
+const mockStackExchangeQuestions = `{
+	"items": [
+		{
+			"tags": ["go", "postgresql"],
+			"owner": {"display_name": "gopher"},
+			"is_answered": true,
+			"view_count": 1500,
+			"answer_count": 3,
+			"score": 25,
+			"creation_date": 1724140800,
+			"question_id": 78900001,
+			"link": "https://stackoverflow.com/questions/78900001/how-to-use-pgx-with-context",
+			"title": "How to use pgx with context &amp; timeouts?"
+		},
+		{
+			"tags": ["go"],
+			"is_answered": false,
+			"view_count": 40,
+			"answer_count": 0,
+			"score": 2,
+			"creation_date": 1724144400,
+			"question_id": 78900002,
+			"link": "https://stackoverflow.com/questions/78900002/why-doesn-t-my-goroutine-stop",
+			"title": "Why doesn&#39;t my goroutine stop?"
+		}
+	],
+	"has_more": true,
+	"quota_max": 300,
+	"quota_remaining": 280,
+	"backoff": 10
+}`
+
+// pausingMockClient is a MockHTTPClient recording the hosts it is asked to pause
+type pausingMockClient struct {
+	*MockHTTPClient
+	paused map[string]time.Time
+}
+
+// PauseHost implements http.HostPauser
+func (m *pausingMockClient) PauseHost(host string, until time.Time) {
+	m.paused[host] = until
+}
+
+func TestStackExchangeRepository_Fetch(t *testing.T) {
+	now := time.Unix(1724200000, 0)
+
+	var requestedURL string
+	mockClient := &pausingMockClient{
+		MockHTTPClient: &MockHTTPClient{
+			GetJSONFunc: func(url string, v any) error {
+				requestedURL = url
+				return json.Unmarshal([]byte(mockStackExchangeQuestions), v)
+			},
+		},
+		paused: make(map[string]time.Time),
+	}
+	var _ http.HostPauser = mockClient
+
+	setTestNow(t, now)
+	repo := &StackExchangeRepository{httpClient: mockClient}
+	source := models.Source{
+		Name:      "StackOverflowGo",
+		Type:      "stackexchange",
+		URL:       "https://api.stackexchange.com/2.3",
+		Limit:     5,
+		SubSource: "stackoverflow",
+		Tags:      []string{"go;postgresql"},
+	}
+
+	news, err := repo.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	parsed, err := url.Parse(requestedURL)
+	if err != nil {
+		t.Fatalf("Failed to parse requested URL: %v", err)
+	}
+	query := parsed.Query()
+	if parsed.Path != "/2.3/questions" || query.Get("site") != "stackoverflow" || query.Get("sort") != "hot" ||
+		query.Get("tagged") != "go;postgresql" || query.Get("pagesize") != "5" || query.Has("fromdate") {
+		t.Errorf("Unexpected questions URL: %s", requestedURL)
+	}
+
+	// The backoff asked by the API holds back the following requests
+	if until := mockClient.paused["api.stackexchange.com"]; !until.Equal(now.Add(10 * time.Second)) {
+		t.Errorf("Expected the API host to be paused until %v, got %v", now.Add(10*time.Second), until)
+	}
+
+	if len(news) != 2 {
+		t.Fatalf("Expected 2 news items, got %d", len(news))
+	}
+	first := news[0]
+	if first.Title != "How to use pgx with context & timeouts?" || first.Score != 25 || first.Comments != 3 ||
+		first.Source != "Stack Exchange" || first.SubSource != "go, postgresql" {
+		t.Errorf("Unexpected item: %+v", first)
+	}
+	if first.Description != "Score: 25, Answers: 3, Views: 1500" {
+		t.Errorf("Unexpected description: '%s'", first.Description)
+	}
+	if news[1].Title != "Why doesn't my goroutine stop?" {
+		t.Errorf("Expected the decoded title, got '%s'", news[1].Title)
+	}
+}
+
+func TestStackExchangeRepository_Fetch_QuotaExceeded(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		GetJSONFunc: func(url string, v any) error {
+			return &http.StatusError{
+				StatusCode: 400,
+				Body:       `{"error_id": 502, "error_message": "too many requests from this IP", "error_name": "throttle_violation"}`,
+			}
+		},
+	}
+
+	repo := &StackExchangeRepository{httpClient: mockClient}
+	source := models.Source{Name: "StackOverflowGo", Type: "stackexchange", Limit: 5, SubSource: "stackoverflow"}
+
+	_, err := repo.Fetch(context.Background(), source)
+	if err == nil || !strings.Contains(err.Error(), "quota exceeded") {
+		t.Fatalf("Expected a quota error, got %v", err)
+	}
+}
+
+func TestStackExchangeRepository_QuestionsURL(t *testing.T) {
+	now := time.Unix(1724200000, 0)
+	setTestNow(t, now)
+	repo := &StackExchangeRepository{}
+
+	testCases := []struct {
+		source    models.Source
+		expected  string
+		expectErr bool
+	}{
+		{
+			models.Source{SubSource: "stackoverflow", Limit: 10, Listing: "votes", Tags: []string{"go", "postgresql"}},
+			"https://api.stackexchange.com/2.3/questions?fromdate=1724113600&order=desc&pagesize=10&site=stackoverflow&sort=votes&tagged=go%3Bpostgresql",
+			false,
+		},
+		{
+			models.Source{SubSource: "serverfault", Limit: 10, Listing: "Activity", TimeWindow: "all"},
+			"https://api.stackexchange.com/2.3/questions?order=desc&pagesize=10&site=serverfault&sort=activity",
+			false,
+		},
+		{models.Source{Limit: 10}, "", true},
+		{models.Source{SubSource: "stackoverflow", Listing: "newest"}, "", true},
+		{models.Source{SubSource: "stackoverflow", TimeWindow: "week"}, "", true},
+	}
+
+	for _, tc := range testCases {
+		questionsURL, err := repo.questionsURL(tc.source)
+		if tc.expectErr {
+			if err == nil {
+				t.Errorf("%+v: expected an error, got %s", tc.source, questionsURL)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v: expected no error, got %v", tc.source, err)
+			continue
+		}
+		if questionsURL != tc.expected {
+			t.Errorf("%+v: expected %s, got %s", tc.source, tc.expected, questionsURL)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// now returns the current time of the sources reading a time window or caching a token,
+// tests set a fixed clock with setTestNow
+var now = time.Now
+
+// namedTimeWindows maps the named time windows of sources reading a period of time to their duration.
+// "all" disables the time window.
+var namedTimeWindows = map[string]time.Duration{
+	"hour":  time.Hour,
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"year":  365 * 24 * time.Hour,
+	"all":   0,
+}
+
+// parseTimeWindow returns the duration of a named time window or of a Go duration such as "36h".
+// "day" is used when none is configured, matching the daily run of the bot.
+func parseTimeWindow(timeWindow string) (time.Duration, error) {
+	timeWindow = strings.ToLower(timeWindow)
+	if timeWindow == "" {
+		timeWindow = "day"
+	}
+
+	if window, ok := namedTimeWindows[timeWindow]; ok {
+		return window, nil
+	}
+
+	window, err := time.ParseDuration(timeWindow)
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf("%q, expected hour, day, week, month, year, all or a duration such as 36h", timeWindow)
+	}
+
+	return window, nil
+}
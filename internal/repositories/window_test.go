@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+)
+
+// setTestNow sets the clock of the repositories to a fixed time until the end of the test
+func setTestNow(t *testing.T, at time.Time) {
+	previous := now
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = previous })
+}
+
+func TestParseTimeWindow(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":     24 * time.Hour,
+		"Week": 7 * 24 * time.Hour,
+		"all":  0,
+		"36h":  36 * time.Hour,
+	}
+	for timeWindow, expected := range tests {
+		window, err := parseTimeWindow(timeWindow)
+		if err != nil || window != expected {
+			t.Errorf("%q: expected %v, got %v (%v)", timeWindow, expected, window, err)
+		}
+	}
+
+	for _, timeWindow := range []string{"fortnight", "-1h"} {
+		if _, err := parseTimeWindow(timeWindow); err == nil {
+			t.Errorf("%q: expected an error, got nil", timeWindow)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/utils"
+)
+
+// ClusterNews merges the news items of different sections whose titles are similar, such as a blog
+// post, its GitHub release and a write-up of it, and returns the number of items merged away.
+// Titles are compared by the share of words they have in common, and an item joins the most similar
+// earlier item of another section whose similarity is at least threshold, between 0 and 1.
+// Merging follows DeduplicateNews: the earlier item is kept with the discussions of the others.
+// Items of the same section are never merged, as a source rarely lists the same story twice.
+func ClusterNews(digest *models.Digest, threshold float64) int {
+	type candidate struct {
+		section, item int
+		tokens        []string
+	}
+
+	var kept []candidate
+	removed := make(map[[2]int]bool)
+	for s, section := range digest.Sections {
+		if section.Status == models.SectionFailed {
+			continue
+		}
+
+		for i, news := range section.Items {
+			tokens := utils.TitleTokens(news.Title)
+			if len(tokens) == 0 {
+				continue
+			}
+
+			// The earliest of equally similar items wins
+			best, bestSimilarity := -1, 0.0
+			for k, other := range kept {
+				if other.section == s {
+					continue
+				}
+				similarity := utils.TitleSimilarity(other.tokens, tokens)
+				if similarity >= threshold && (best < 0 || similarity > bestSimilarity) {
+					best, bestSimilarity = k, similarity
+				}
+			}
+			if best < 0 {
+				kept = append(kept, candidate{section: s, item: i, tokens: tokens})
+				continue
+			}
+
+			at := kept[best]
+			mergeNews(&digest.Sections[at.section].Items[at.item], digest.Sections[at.section].Title, news, section.Title)
+			removed[[2]int{s, i}] = true
+		}
+	}
+
+	if len(removed) == 0 {
+		return 0
+	}
+
+	for s := range digest.Sections {
+		section := &digest.Sections[s]
+		items := section.Items[:0]
+		for i, news := range section.Items {
+			if !removed[[2]int{s, i}] {
+				items = append(items, news)
+			}
+		}
+
+		section.Items = items
+		if len(section.Items) == 0 && section.Status == models.SectionOK {
+			section.Status = models.SectionEmpty
+		}
+	}
+
+	return len(removed)
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+)
+
+// newClusterDigest returns a digest where the Go 1.23 release is posted under three different links
+func newClusterDigest() *models.Digest {
+	return &models.Digest{
+		Sections: []models.Section{
+			{
+				Name:   "GoBlog",
+				Title:  "The Go Blog",
+				Status: models.SectionOK,
+				Items: []models.News{
+					{Title: "Go 1.23 is released", URL: "https://go.dev/blog/go1.23", Source: "The Go Blog"},
+					{Title: "Go 1.23 release notes", URL: "https://go.dev/doc/go1.23", Source: "The Go Blog"},
+				},
+			},
+			{
+				Name:   "GoReleases",
+				Title:  "Go releases",
+				Status: models.SectionOK,
+				Items: []models.News{
+					{Title: "Release Go 1.23", URL: "https://github.com/golang/go/releases/tag/go1.23", Source: "GitHub", Score: 10,
+						ID: "github_releases:golang/go@go1.23"},
+				},
+			},
+			{
+				Name:   "InfoQ",
+				Title:  "InfoQ",
+				Status: models.SectionOK,
+				Items: []models.News{
+					{Title: "Rust 1.80 is released", URL: "https://www.infoq.com/news/rust-1-80", Source: "InfoQ"},
+					{Title: "Go 1.23 released with range over func", URL: "https://www.infoq.com/news/go-1-23", Source: "InfoQ", Score: 5,
+						DiscussionURL: "https://www.infoq.com/news/go-1-23#comments"},
+				},
+			},
+		},
+	}
+}
+
+// TestClusterNews tests that items of different sources with similar titles are merged into the first one
+func TestClusterNews(t *testing.T) {
+	digest := newClusterDigest()
+
+	if merged := ClusterNews(digest, 0.5); merged != 2 {
+		t.Errorf("Expected 2 merged items, got %d", merged)
+	}
+
+	blog := digest.Sections[0].Items
+	if len(blog) != 2 {
+		t.Fatalf("Expected both Go Blog items to be kept, got %+v", blog)
+	}
+	expectedDiscussions := []models.Discussion{
+		{Section: "The Go Blog", Source: "The Go Blog", URL: "https://go.dev/blog/go1.23", ItemURL: "https://go.dev/blog/go1.23"},
+		{Section: "Go releases", Source: "GitHub", URL: "https://github.com/golang/go/releases/tag/go1.23", Score: 10,
+			ID: "github_releases:golang/go@go1.23", ItemURL: "https://github.com/golang/go/releases/tag/go1.23"},
+		{Section: "InfoQ", Source: "InfoQ", URL: "https://www.infoq.com/news/go-1-23#comments", Score: 5,
+			ItemURL: "https://www.infoq.com/news/go-1-23"},
+	}
+	if !reflect.DeepEqual(blog[0].Discussions, expectedDiscussions) || blog[0].Score != 15 {
+		t.Errorf("Expected the release to gather every discussion, got %+v", blog[0])
+	}
+	if blog[1].Discussions != nil {
+		t.Errorf("Expected the release notes of the same source not to be merged, got %+v", blog[1])
+	}
+
+	if len(digest.Sections[1].Items) != 0 || digest.Sections[1].Status != models.SectionEmpty {
+		t.Errorf("Expected the Go releases section to be emptied, got %+v", digest.Sections[1])
+	}
+	if infoq := digest.Sections[2].Items; len(infoq) != 1 || infoq[0].Title != "Rust 1.80 is released" {
+		t.Errorf("Expected only the Rust story to be left in InfoQ, got %+v", infoq)
+	}
+}
+
+// TestClusterNews_Threshold tests that titles less similar than the threshold are kept apart
+func TestClusterNews_Threshold(t *testing.T) {
+	digest := newClusterDigest()
+
+	if merged := ClusterNews(digest, 1); merged != 1 {
+		t.Errorf("Expected only the identical titles to be merged, got %d", merged)
+	}
+	if len(digest.Sections[1].Items) != 0 || len(digest.Sections[2].Items) != 2 {
+		t.Errorf("Expected only the GitHub release to be merged, got %+v", digest.Sections)
+	}
+}
+
+// TestClusterNews_MergedDuplicates tests that an item merged by DeduplicateNews brings its discussions along
+func TestClusterNews_MergedDuplicates(t *testing.T) {
+	digest := newClusterDigest()
+	digest.Sections = append(digest.Sections, models.Section{
+		Name:   "HackerNews",
+		Title:  "Hacker News",
+		Status: models.SectionOK,
+		Items: []models.News{
+			{Title: "Go 1.23 released with range-over-func", URL: "https://infoq.com/news/go-1-23/", Source: "Hacker News", Score: 100,
+				DiscussionURL: "https://news.ycombinator.com/item?id=1"},
+		},
+	})
+
+	DeduplicateNews(digest)
+	ClusterNews(digest, 0.5)
+
+	release := digest.Sections[0].Items[0]
+	if release.Score != 115 || len(release.Discussions) != 4 || release.Discussions[3].Section != "Hacker News" {
+		t.Errorf("Expected the Hacker News discussion to follow the InfoQ item, got %+v", release)
+	}
+	if digest.Sections[3].Status != models.SectionEmpty {
+		t.Errorf("Expected the Hacker News section to be emptied, got %+v", digest.Sections[3])
+	}
+}
+
+// TestClusterNews_DifferentStories tests that similar-looking headlines of different stories are kept
+// apart at the threshold suggested in the README
+func TestClusterNews_DifferentStories(t *testing.T) {
+	pairs := [][2]string{
+		{"What's new in Go 1.23", "What's new in Rust 1.80"},
+		{"What's new in Go", "What's new in Rust"},
+		{"Go 1.22 release notes", "Go 1.23 release notes"},
+		{"How I test Go code", "Why I don't test Go code"},
+		{"Go generics explained", "Go channels explained"},
+		{"Go 1.23 is released", "Go 1.23 is delayed"},
+	}
+
+	for _, pair := range pairs {
+		digest := &models.Digest{
+			Sections: []models.Section{
+				{Name: "HackerNews", Title: "Hacker News", Status: models.SectionOK, Items: []models.News{{Title: pair[0], URL: "https://example.com/a"}}},
+				{Name: "RedditGo", Title: "Reddit r/golang", Status: models.SectionOK, Items: []models.News{{Title: pair[1], URL: "https://example.com/b"}}},
+			},
+		}
+
+		if merged := ClusterNews(digest, 0.6); merged != 0 {
+			t.Errorf("Expected %q and %q to be kept apart, got %d merged", pair[0], pair[1], merged)
+		}
+	}
+}
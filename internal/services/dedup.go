@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/utils"
+)
+
+// DeduplicateNews merges the news items of a digest that link to the same page, recognised by their
+// canonical URL, and returns the number of items merged away.
+// The first item in the digest order is kept, its score and comments become the sums over all
+// duplicates and its discussions list the discussion of every duplicate. The other duplicates are
+// removed from their sections, and a section left without items is marked as empty.
+func DeduplicateNews(digest *models.Digest) int {
+	type position struct{ section, item int }
+
+	kept := make(map[string]position)
+	merged := 0
+	for s := range digest.Sections {
+		section := &digest.Sections[s]
+		if section.Status == models.SectionFailed {
+			continue
+		}
+
+		items := section.Items[:0]
+		for _, news := range section.Items {
+			key := utils.CanonicalURL(news.URL)
+			if key == "" {
+				items = append(items, news)
+				continue
+			}
+
+			at, ok := kept[key]
+			if !ok {
+				kept[key] = position{section: s, item: len(items)}
+				items = append(items, news)
+				continue
+			}
+
+			// The kept item may be in this section, whose items are being rewritten
+			var original *models.News
+			if at.section == s {
+				original = &items[at.item]
+			} else {
+				original = &digest.Sections[at.section].Items[at.item]
+			}
+			mergeNews(original, digest.Sections[at.section].Title, news, section.Title)
+			merged++
+		}
+
+		section.Items = items
+		if len(section.Items) == 0 && section.Status == models.SectionOK {
+			section.Status = models.SectionEmpty
+		}
+	}
+
+	return merged
+}
+
+// mergeNews merges a duplicate found in the section duplicateSection into the news item kept in the
+// section originalSection, adding up their scores and comments and listing the duplicate discussions
+func mergeNews(original *models.News, originalSection string, duplicate models.News, duplicateSection string) {
+	if len(original.Discussions) == 0 {
+		original.Discussions = []models.Discussion{discussionOf(*original, originalSection)}
+	}
+
+	// A duplicate merging other items already lists their discussions
+	if len(duplicate.Discussions) > 0 {
+		original.Discussions = append(original.Discussions, duplicate.Discussions...)
+	} else {
+		original.Discussions = append(original.Discussions, discussionOf(duplicate, duplicateSection))
+	}
+
+	original.Score += duplicate.Score
+	original.Comments += duplicate.Comments
+}
+
+// discussionOf returns the discussion of a news item found in a section
+func discussionOf(news models.News, section string) models.Discussion {
+	discussionURL := news.DiscussionURL
+	if discussionURL == "" {
+		discussionURL = news.URL
+	}
+
+	return models.Discussion{
+		Section:  section,
+		Source:   news.Source,
+		URL:      discussionURL,
+		Score:    news.Score,
+		Comments: news.Comments,
+		ID:       news.ID,
+		ItemURL:  news.URL,
+	}
+}
+
+// alsoDiscussedOn returns the links to the other discussions of a merged news item,
+// e.g. " — also discussed on [Reddit Golang](https://...)", or "" for an item found once
+func alsoDiscussedOn(news models.News) string {
+	if len(news.Discussions) < 2 {
+		return ""
+	}
+
+	links := make([]string, 0, len(news.Discussions)-1)
+	for _, discussion := range news.Discussions[1:] {
+		links = append(links, fmt.Sprintf("[%s](%s)", discussion.Section, discussion.URL))
+	}
+	return " — also discussed on " + strings.Join(links, ", ")
+}
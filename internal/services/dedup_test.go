@@ -0,0 +1,132 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
+)
+
+// TestDeduplicateNews tests that the same article found in several sources is merged into the first one
+func TestDeduplicateNews(t *testing.T) {
+	digest := &models.Digest{
+		Sections: []models.Section{
+			{
+				Name:   "HackerNews",
+				Title:  "Hacker News",
+				Status: models.SectionOK,
+				Items: []models.News{
+					{Title: "Go 1.23 is released", URL: "https://go.dev/blog/go1.23", Source: "Hacker News", Score: 400, Comments: 120,
+						ID: "hackernews:1", DiscussionURL: "https://news.ycombinator.com/item?id=1"},
+					{Title: "Ask HN: Favourite editor?", URL: "https://news.ycombinator.com/item?id=2", Source: "Hacker News", Score: 50},
+				},
+			},
+			{
+				Name:   "Broken",
+				Title:  "Broken",
+				Status: models.SectionFailed,
+			},
+			{
+				Name:   "RedditGo",
+				Title:  "Reddit r/golang",
+				Status: models.SectionOK,
+				Items: []models.News{
+					{Title: "Go 1.23 released!", URL: "http://www.go.dev/blog/go1.23/?utm_source=reddit", Source: "Reddit", Score: 250, Comments: 80,
+						ID: "reddit:t3_3", DiscussionURL: "https://www.reddit.com/r/golang/comments/3/go_123/"},
+					{Title: "Weekly thread", URL: "https://www.reddit.com/r/golang/comments/4/weekly/", Source: "Reddit", Score: 10},
+				},
+			},
+			{
+				Name:   "GoBlog",
+				Title:  "The Go Blog",
+				Status: models.SectionOK,
+				Items: []models.News{
+					{Title: "Go 1.23 is released", URL: "https://go.dev/blog/go1.23#top", Source: "The Go Blog"},
+				},
+			},
+		},
+	}
+
+	if merged := DeduplicateNews(digest); merged != 2 {
+		t.Errorf("Expected 2 merged items, got %d", merged)
+	}
+
+	kept := digest.Sections[0].Items[0]
+	if kept.Score != 650 || kept.Comments != 200 {
+		t.Errorf("Expected the combined score 650 and comments 200, got %d and %d", kept.Score, kept.Comments)
+	}
+	expectedDiscussions := []models.Discussion{
+		{Section: "Hacker News", Source: "Hacker News", URL: "https://news.ycombinator.com/item?id=1", Score: 400, Comments: 120,
+			ID: "hackernews:1", ItemURL: "https://go.dev/blog/go1.23"},
+		{Section: "Reddit r/golang", Source: "Reddit", URL: "https://www.reddit.com/r/golang/comments/3/go_123/", Score: 250, Comments: 80,
+			ID: "reddit:t3_3", ItemURL: "http://www.go.dev/blog/go1.23/?utm_source=reddit"},
+		{Section: "The Go Blog", Source: "The Go Blog", URL: "https://go.dev/blog/go1.23#top", ItemURL: "https://go.dev/blog/go1.23#top"},
+	}
+	if !reflect.DeepEqual(kept.Discussions, expectedDiscussions) {
+		t.Errorf("Expected discussions %+v, got %+v", expectedDiscussions, kept.Discussions)
+	}
+
+	if len(digest.Sections[0].Items) != 2 || digest.Sections[0].Items[1].Discussions != nil {
+		t.Errorf("Expected the other Hacker News item to be left alone, got %+v", digest.Sections[0].Items)
+	}
+	if len(digest.Sections[2].Items) != 1 || digest.Sections[2].Items[0].Title != "Weekly thread" {
+		t.Errorf("Expected only the weekly thread to be left in Reddit, got %+v", digest.Sections[2].Items)
+	}
+	if len(digest.Sections[3].Items) != 0 || digest.Sections[3].Status != models.SectionEmpty {
+		t.Errorf("Expected the Go Blog section to be emptied, got %+v", digest.Sections[3])
+	}
+	if digest.Sections[1].Status != models.SectionFailed {
+		t.Errorf("Expected the failed section to stay failed, got %s", digest.Sections[1].Status)
+	}
+}
+
+// TestDeduplicateNews_SameSection tests that duplicates within one section are merged too
+func TestDeduplicateNews_SameSection(t *testing.T) {
+	digest := &models.Digest{
+		Sections: []models.Section{
+			{
+				Name:   "Lobsters",
+				Title:  "Lobsters",
+				Status: models.SectionOK,
+				Items: []models.News{
+					{Title: "First", URL: "https://example.com/a", Score: 3},
+					{Title: "Second", URL: "https://example.com/b", Score: 2},
+					{Title: "First again", URL: "https://example.com/a/", Score: 1},
+					{Title: "No link"},
+					{Title: "No link either"},
+				},
+			},
+		},
+	}
+
+	if merged := DeduplicateNews(digest); merged != 1 {
+		t.Errorf("Expected 1 merged item, got %d", merged)
+	}
+
+	items := digest.Sections[0].Items
+	if len(items) != 4 || items[0].Title != "First" || items[1].Title != "Second" || items[2].Title != "No link" {
+		t.Fatalf("Unexpected items: %+v", items)
+	}
+	if items[0].Score != 4 || len(items[0].Discussions) != 2 {
+		t.Errorf("Expected the duplicate to be merged into the first item, got %+v", items[0])
+	}
+}
+
+// TestAlsoDiscussedOn tests the links rendered after a merged news item
+func TestAlsoDiscussedOn(t *testing.T) {
+	news := models.News{
+		Discussions: []models.Discussion{
+			{Section: "Hacker News", URL: "https://news.ycombinator.com/item?id=1"},
+			{Section: "Reddit r/golang", URL: "https://www.reddit.com/r/golang/comments/3/"},
+			{Section: "InfoQ", URL: "https://www.infoq.com/news/go"},
+		},
+	}
+
+	expected := " — also discussed on [Reddit r/golang](https://www.reddit.com/r/golang/comments/3/), [InfoQ](https://www.infoq.com/news/go)"
+	if result := alsoDiscussedOn(news); result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+	if result := alsoDiscussedOn(models.News{}); result != "" {
+		t.Errorf("Expected nothing for an item found once, got %q", result)
+	}
+}
@@ -37,7 +37,7 @@ func NewGithubService(token, owner, repo string) *GithubService {
 }
 
 // CreateIssue creates a new GitHub issue with the given title and body
-func (s *GithubService) CreateIssue(title, body string) (*github.Issue, error) {
+func (s *GithubService) CreateIssue(ctx context.Context, title, body string) (*github.Issue, error) {
 	// Create issue request
 	issue := &github.IssueRequest{
 		Title: github.String(title),
@@ -45,7 +45,7 @@ func (s *GithubService) CreateIssue(title, body string) (*github.Issue, error) {
 	}
 
 	// Create issue
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	createdIssue, _, err := s.client.Issues.Create(ctx, s.owner, s.repo, issue)
@@ -56,28 +56,24 @@ func (s *GithubService) CreateIssue(title, body string) (*github.Issue, error) {
 	return createdIssue, nil
 }
 
-// GenerateIssueContent generates the content for a GitHub issue
-func (s *GithubService) GenerateIssueContent(newsMap map[string][]models.News) (string, error) {
-	// Get current date
-	now := time.Now().UTC()
-	date := now.Format("2006-01-02")
-
+// GenerateIssueContent generates the content for a GitHub issue, with the sections in the digest order
+func (s *GithubService) GenerateIssueContent(digest *models.Digest) (string, error) {
 	// Build issue content
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("# %s\n\n", date))
+	sb.WriteString(fmt.Sprintf("# %s\n\n", digest.Date()))
 
 	// Add news for each source
-	for source, newsList := range newsMap {
-		if len(newsList) == 0 {
+	for _, section := range digest.Sections {
+		if len(section.Items) == 0 {
 			continue
 		}
 
 		// Add source header
-		sb.WriteString(fmt.Sprintf("## %s\n\n", source))
+		sb.WriteString(fmt.Sprintf("## %s\n\n", section.Title))
 
 		// Add news items - only titles, no descriptions
-		for i, news := range newsList {
-			sb.WriteString(fmt.Sprintf("%d. [%s](%s)\n", i+1, news.Title, news.URL))
+		for i, news := range section.Items {
+			sb.WriteString(fmt.Sprintf("%d. [%s](%s)%s%s\n", i+1, news.Title, news.URL, alsoDiscussedOn(news), previouslySent(news)))
 		}
 
 		sb.WriteString("\n")
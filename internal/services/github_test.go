@@ -55,55 +55,77 @@ func TestGenerateIssueContent(t *testing.T) {
 	service := NewGithubService("test-token", "test-owner", "test-repo")
 
 	// Create test news data
-	newsMap := map[string][]models.News{
-		"HackerNews": {
+	digest := &models.Digest{
+		StartedAt: time.Date(2023, 1, 2, 6, 0, 0, 0, time.UTC),
+		Sections: []models.Section{
 			{
-				Title:       "Test HN Story 1",
-				URL:         "https://example.com/hn1",
-				Description: "Description 1",
-				Source:      "Hacker News",
-				PublishedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
-				Score:       100,
-				Comments:    10,
+				Name:   "RedditGo",
+				Title:  "Reddit r/golang",
+				Status: models.SectionOK,
+				Items: []models.News{
+					{
+						Title:       "Test Reddit Post 1",
+						URL:         "https://example.com/reddit1",
+						Description: "Description 1",
+						Source:      "Reddit",
+						SubSource:   "golang",
+						PublishedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+						Score:       300,
+						Comments:    30,
+					},
+				},
 			},
 			{
-				Title:       "Test HN Story 2",
-				URL:         "https://example.com/hn2",
-				Description: "Description 2",
-				Source:      "Hacker News",
-				PublishedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
-				Score:       200,
-				Comments:    20,
+				Name:   "HackerNews",
+				Title:  "HackerNews",
+				Status: models.SectionOK,
+				Items: []models.News{
+					{
+						Title:       "Test HN Story 1",
+						URL:         "https://example.com/hn1",
+						Description: "Description 1",
+						Source:      "Hacker News",
+						PublishedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+						Score:       100,
+						Comments:    10,
+					},
+					{
+						Title:       "Test HN Story 2",
+						URL:         "https://example.com/hn2",
+						Description: "Description 2",
+						Source:      "Hacker News",
+						PublishedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+						Score:       200,
+						Comments:    20,
+					},
+				},
 			},
+			{Name: "EmptySource", Title: "EmptySource", Status: models.SectionEmpty},
+			{Name: "FailedSource", Title: "FailedSource", Status: models.SectionFailed, Error: "timed out"},
 		},
-		"RedditGo": {
-			{
-				Title:       "Test Reddit Post 1",
-				URL:         "https://example.com/reddit1",
-				Description: "Description 1",
-				Source:      "Reddit",
-				SubSource:   "golang",
-				PublishedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
-				Score:       300,
-				Comments:    30,
-			},
-		},
-		"EmptySource": {},
 	}
 
 	// Call the method being tested
-	content, err := service.GenerateIssueContent(newsMap)
+	content, err := service.GenerateIssueContent(digest)
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	// Check the content
-	// Get current date for comparison
-	date := time.Now().UTC().Format("2006-01-02")
+	// The content is rendered in the digest order, empty and failed sections are left out
+	expected := `# 2023-01-02
+
+## Reddit r/golang
 
-	// Expected content should have the date as a header
-	if !strings.Contains(content, "# "+date) {
-		t.Errorf("Expected content to contain date header '# %s', got: %s", date, content)
+1. [Test Reddit Post 1](https://example.com/reddit1)
+
+## HackerNews
+
+1. [Test HN Story 1](https://example.com/hn1)
+2. [Test HN Story 2](https://example.com/hn2)
+
+`
+	if content != expected {
+		t.Errorf("Expected content:\n%s\ngot:\n%s", expected, content)
 	}
 
 	// Expected content should have HackerNews section
@@ -111,8 +133,8 @@ func TestGenerateIssueContent(t *testing.T) {
 		t.Errorf("Expected content to contain HackerNews section, got: %s", content)
 	}
 
-	// Expected content should have RedditGo section
-	if !strings.Contains(content, "## RedditGo") {
+	// Expected content should have the RedditGo section under its title
+	if !strings.Contains(content, "## Reddit r/golang") {
 		t.Errorf("Expected content to contain RedditGo section, got: %s", content)
 	}
 
@@ -120,6 +142,9 @@ func TestGenerateIssueContent(t *testing.T) {
 	if strings.Contains(content, "## EmptySource") {
 		t.Errorf("Expected content to not contain EmptySource section, got: %s", content)
 	}
+	if strings.Contains(content, "## FailedSource") {
+		t.Errorf("Expected content to not contain FailedSource section, got: %s", content)
+	}
 
 	// Expected content should have HackerNews stories
 	if !strings.Contains(content, "1. [Test HN Story 1](https://example.com/hn1)") {
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/ducminhgd/gossip-bot/config"
+	"github.com/ducminhgd/gossip-bot/internal/models"
+)
+
+// ApplyHistory drops the news items of a digest that were published within the history window,
+// or marks them with their last publication time when the history mode is config.HistoryModeMark,
+// and returns the number of items dropped or marked. A section left without items is marked as empty.
+// It does nothing when the history is disabled.
+func (s *NewsService) ApplyHistory(digest *models.Digest) int {
+	if s.history == nil {
+		return 0
+	}
+
+	since := digest.StartedAt.Add(-s.historyWindow)
+	repeated := 0
+	for i := range digest.Sections {
+		section := &digest.Sections[i]
+		if section.Status == models.SectionFailed {
+			continue
+		}
+
+		items := section.Items[:0]
+		for _, news := range section.Items {
+			entry, ok := s.history.Lookup(news)
+			if !ok || entry.LastSentAt.Before(since) {
+				items = append(items, news)
+				continue
+			}
+
+			repeated++
+			if s.historyMode == config.HistoryModeMark {
+				lastSentAt := entry.LastSentAt
+				news.PreviouslySentAt = &lastSentAt
+				items = append(items, news)
+			}
+		}
+
+		section.Items = items
+		if len(section.Items) == 0 && section.Status == models.SectionOK {
+			section.Status = models.SectionEmpty
+		}
+	}
+
+	return repeated
+}
+
+// RecordHistory records the news items of a published digest and the items merged into them in the
+// history, forgets the items published before the history window, and records the run of every source
+// that was fetched.
+// It does nothing when the history is disabled.
+func (s *NewsService) RecordHistory(digest *models.Digest) error {
+	if s.history == nil {
+		return nil
+	}
+
+	// A failed source is read again from its previous run next time
+	var items []models.News
+	var fetched []string
+	for _, section := range digest.Sections {
+		for _, news := range section.Items {
+			items = append(items, news)
+			items = append(items, mergedItems(news)...)
+		}
+		if section.Status != models.SectionFailed {
+			fetched = append(fetched, section.Name)
+		}
+	}
+
+	if err := s.history.Commit(items, fetched, digest.StartedAt, digest.StartedAt.Add(-s.historyWindow)); err != nil {
+		return fmt.Errorf("failed to record history: %w", err)
+	}
+
+	return nil
+}
+
+// mergedItems returns the news items merged into a news item of the digest, so that the history
+// recognises them by their own ID and link, which differ from the kept item's when merged by title
+func mergedItems(news models.News) []models.News {
+	if len(news.Discussions) < 2 {
+		return nil
+	}
+
+	items := make([]models.News, 0, len(news.Discussions)-1)
+	for _, discussion := range news.Discussions[1:] {
+		items = append(items, models.News{ID: discussion.ID, URL: discussion.ItemURL, Title: news.Title})
+	}
+	return items
+}
+
+// previouslySent returns when a repeated news item was last published,
+// e.g. " — sent before on 2024-08-19", or "" for a new item
+func previouslySent(news models.News) string {
+	if news.PreviouslySentAt == nil {
+		return ""
+	}
+	return " — sent before on " + news.PreviouslySentAt.UTC().Format("2006-01-02")
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ducminhgd/gossip-bot/config"
+	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/repositories"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
+)
+
+// newHistoryDigest returns a digest of one Hacker News section started at the given time
+func newHistoryDigest(startedAt time.Time, items ...models.News) *models.Digest {
+	return &models.Digest{
+		StartedAt: startedAt,
+		Sections: []models.Section{
+			{Name: "HackerNews", Title: "Hacker News", Status: models.SectionOK, Items: items},
+		},
+	}
+}
+
+// newHistoryService returns a NewsService with a JSON history in a temporary directory
+func newHistoryService(t *testing.T, mode string) *NewsService {
+	store, err := repositories.NewJSONHistoryStore(filepath.Join(t.TempDir(), "history.json"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	return &NewsService{
+		history:       store,
+		historyWindow: 72 * time.Hour,
+		historyMode:   mode,
+	}
+}
+
+// TestApplyHistory_Drop tests that the news items sent within the window are dropped
+func TestApplyHistory_Drop(t *testing.T) {
+	service := newHistoryService(t, config.HistoryModeDrop)
+
+	firstRun := time.Date(2024, 8, 20, 0, 0, 0, 0, time.UTC)
+	oldStory := models.News{ID: "hackernews:1", Title: "Old story", URL: "https://example.com/old"}
+	if err := service.RecordHistory(newHistoryDigest(firstRun, oldStory)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	newStory := models.News{ID: "hackernews:2", Title: "New story", URL: "https://example.com/new"}
+	digest := newHistoryDigest(firstRun.Add(24*time.Hour), oldStory, newStory)
+	if repeated := service.ApplyHistory(digest); repeated != 1 {
+		t.Errorf("Expected 1 repeated item, got %d", repeated)
+	}
+	if items := digest.Sections[0].Items; len(items) != 1 || items[0].ID != "hackernews:2" {
+		t.Errorf("Expected only the new story to be left, got %+v", items)
+	}
+
+	// Once the window has passed the story can be sent again
+	digest = newHistoryDigest(firstRun.Add(96*time.Hour), oldStory)
+	if repeated := service.ApplyHistory(digest); repeated != 0 || len(digest.Sections[0].Items) != 1 {
+		t.Errorf("Expected the old story to be sent again, got %+v", digest.Sections[0])
+	}
+
+	// A section left without items is empty
+	digest = newHistoryDigest(firstRun.Add(time.Hour), oldStory)
+	service.ApplyHistory(digest)
+	if digest.Sections[0].Status != models.SectionEmpty {
+		t.Errorf("Expected the section to be empty, got %s", digest.Sections[0].Status)
+	}
+}
+
+// TestApplyHistory_Mark tests that the news items sent within the window are kept and marked
+func TestApplyHistory_Mark(t *testing.T) {
+	service := newHistoryService(t, config.HistoryModeMark)
+	githubService := NewGithubService("test-token", "test-owner", "test-repo")
+
+	firstRun := time.Date(2024, 8, 19, 0, 0, 0, 0, time.UTC)
+	story := models.News{ID: "hackernews:1", Title: "Old story", URL: "https://example.com/old"}
+	if err := service.RecordHistory(newHistoryDigest(firstRun, story)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	digest := newHistoryDigest(firstRun.Add(24*time.Hour), story)
+	if repeated := service.ApplyHistory(digest); repeated != 1 {
+		t.Errorf("Expected 1 repeated item, got %d", repeated)
+	}
+	items := digest.Sections[0].Items
+	if len(items) != 1 || items[0].PreviouslySentAt == nil || !items[0].PreviouslySentAt.Equal(firstRun) {
+		t.Fatalf("Expected the story to be marked as sent on %v, got %+v", firstRun, items)
+	}
+
+	content, err := githubService.GenerateIssueContent(digest)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(content, "1. [Old story](https://example.com/old) — sent before on 2024-08-19\n") {
+		t.Errorf("Expected the repeated story to be marked, got %q", content)
+	}
+}
+
+// TestApplyHistory_Disabled tests that a service without history leaves the digest alone
+func TestApplyHistory_Disabled(t *testing.T) {
+	service := &NewsService{}
+	digest := newHistoryDigest(time.Now(), models.News{ID: "hackernews:1", URL: "https://example.com"})
+
+	if repeated := service.ApplyHistory(digest); repeated != 0 || len(digest.Sections[0].Items) != 1 {
+		t.Errorf("Expected the digest to be left alone, got %+v", digest.Sections[0])
+	}
+	if err := service.RecordHistory(digest); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+// TestRecordHistory_MergedItems tests that the items merged by title are recognised by their own links next time
+func TestRecordHistory_MergedItems(t *testing.T) {
+	service := newHistoryService(t, config.HistoryModeDrop)
+
+	digest := newClusterDigest()
+	digest.StartedAt = time.Date(2024, 8, 20, 0, 0, 0, 0, time.UTC)
+	ClusterNews(digest, 0.5)
+	if err := service.RecordHistory(digest); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The next day the GitHub release and the InfoQ write-up are listed again on their own
+	next := newHistoryDigest(digest.StartedAt.Add(24*time.Hour),
+		models.News{Title: "Release Go 1.23", URL: "https://github.com/golang/go/releases/tag/go1.23/", ID: "github_releases:golang/go@go1.23"},
+		models.News{Title: "Go 1.23 released with range over func", URL: "https://www.infoq.com/news/go-1-23?utm_source=rss"},
+		models.News{Title: "Go 1.23.1 is released", URL: "https://go.dev/blog/go1.23.1"},
+	)
+	if repeated := service.ApplyHistory(next); repeated != 2 {
+		t.Errorf("Expected the 2 merged items to be recognised, got %d", repeated)
+	}
+	if items := next.Sections[0].Items; len(items) != 1 || items[0].Title != "Go 1.23.1 is released" {
+		t.Errorf("Expected only the new item to be left, got %+v", items)
+	}
+}
+
+// TestRecordHistory_Runs tests that the sources of a published digest are fetched since that run next time
+func TestRecordHistory_Runs(t *testing.T) {
+	service := newHistoryService(t, config.HistoryModeDrop)
+
+	startedAt := time.Date(2024, 8, 20, 0, 0, 0, 0, time.UTC)
+	digest := &models.Digest{
+		StartedAt: startedAt,
+		Sections: []models.Section{
+			{Name: "Releases", Status: models.SectionEmpty},
+			{Name: "Broken", Status: models.SectionFailed},
+		},
+	}
+	if err := service.RecordHistory(digest); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var since []time.Time
+	repositories.Register("fake-since", func(httpClient http.HTTPClient) repositories.Fetcher {
+		return funcFetcher(func(ctx context.Context, source models.Source) ([]models.News, error) {
+			since = append(since, source.Since)
+			return nil, nil
+		})
+	})
+	service.httpClient = &MockHTTPClient{}
+	service.sourceTimeout = time.Minute
+	service.concurrency = 1
+	service.sources = []models.Source{
+		{Name: "Releases", Type: "fake-since"},
+		{Name: "Broken", Type: "fake-since"},
+	}
+
+	if _, err := service.FetchAllNews(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(since) != 2 || !since[0].Equal(startedAt) || !since[1].IsZero() {
+		t.Errorf("Expected only the fetched source to be read since the last run, got %v", since)
+	}
+}
@@ -1,12 +1,11 @@
 package services
 
 import (
-	"encoding/base64"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"net/url"
-	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ducminhgd/gossip-bot/config"
@@ -17,395 +16,225 @@ import (
 
 // NewsService handles operations related to news
 type NewsService struct {
-	httpClient      http.HTTPClient
-	sources         []models.Source
-	redditAppConfig *config.RedditAppConfig
-	infoqRepo       *repositories.InfoQRepository
-}
-
-// NewNewsService creates a new NewsService
-func NewNewsService(sources []models.Source) *NewsService {
-	redditConfig, err := config.LoadRedditAppConfig()
-	if err != nil {
-		redditConfig = &config.RedditAppConfig{
-			AppID:     "",
-			AppSecret: "",
-		}
-	}
+	httpClient http.HTTPClient
+	sources    []models.Source
 
-	return &NewsService{
-		httpClient:      http.NewClient(),
-		sources:         sources,
-		redditAppConfig: redditConfig,
-		infoqRepo:       repositories.NewInfoQRepository(),
-	}
-}
+	// concurrency is the maximum number of sources fetched at the same time
+	concurrency int
 
-// FetchAllNews fetches news from all sources
-// If a source can't be crawled, it will be skipped and a warning will be logged
-func (s *NewsService) FetchAllNews() (map[string][]models.News, error) {
-	result := make(map[string][]models.News)
-	var skippedSources []string
+	// sourceTimeout is the deadline for fetching a source without its own timeout
+	sourceTimeout time.Duration
 
-	for _, source := range s.sources {
-		news, err := s.FetchNewsBySource(source)
-		if err != nil {
-			// Log warning and continue with other sources
-			fmt.Printf("WARNING: failed to fetch news from %s: %v\n", source.Name, err)
-			skippedSources = append(skippedSources, source.Name)
-			continue
-		}
-		result[source.Name] = news
-	}
+	// fetchers caches one fetcher per source type, so state such as OAuth tokens is shared between sources
+	fetchers   map[string]repositories.Fetcher
+	fetchersMu sync.Mutex
 
-	// If all sources were skipped, return an error
-	if len(result) == 0 && len(skippedSources) > 0 {
-		return nil, fmt.Errorf("failed to fetch news from any source, skipped: %v", skippedSources)
-	}
+	// history records the published news items, nil disables it
+	history repositories.HistoryStore
 
-	return result, nil
-}
+	// historyWindow is how long a published news item is not sent again
+	historyWindow time.Duration
 
-// FetchNewsBySource fetches news from a specific source
-func (s *NewsService) FetchNewsBySource(source models.Source) ([]models.News, error) {
-	switch strings.ToLower(source.Type) {
-	case "hackernews":
-		return s.fetchHackerNews(source)
-	case "reddit":
-		return s.fetchReddit(source)
-	case "infoq":
-		return s.infoqRepo.FetchArticles(source)
-	default:
-		return nil, fmt.Errorf("unsupported source type: %s", source.Type)
-	}
+	// historyMode is config.HistoryModeDrop or config.HistoryModeMark
+	historyMode string
 }
 
-// fetchHackerNews fetches news from Hacker News
-func (s *NewsService) fetchHackerNews(source models.Source) ([]models.News, error) {
-	// Fetch top stories
-	topStoriesURL := "https://hacker-news.firebaseio.com/v0/topstories.json"
-	var storyIDs []int
-	if err := s.httpClient.GetJSON(topStoriesURL, &storyIDs); err != nil {
-		return nil, fmt.Errorf("failed to fetch top stories: %w", err)
-	}
-
-	// Limit the number of stories
-	if len(storyIDs) > source.Limit {
-		storyIDs = storyIDs[:source.Limit]
-	}
-
-	// Fetch each story
-	var newsList []models.News
-	var skippedStories []int
-
-	for _, id := range storyIDs {
-		storyURL := fmt.Sprintf("https://hacker-news.firebaseio.com/v0/item/%d.json", id)
-		var story map[string]any
-		if err := s.httpClient.GetJSON(storyURL, &story); err != nil {
-			// Log warning and continue with other stories
-			fmt.Printf("WARNING: failed to fetch Hacker News story %d: %v\n", id, err)
-			skippedStories = append(skippedStories, id)
-			continue
-		}
-
-		// Extract story details
-		title, _ := story["title"].(string)
-		url, _ := story["url"].(string)
-		if url == "" {
-			url = fmt.Sprintf("https://news.ycombinator.com/item?id=%d", id)
-		}
-		score, _ := story["score"].(float64)
-		descendants, _ := story["descendants"].(float64)
-		unixTime, _ := story["time"].(float64)
-		publishedAt := time.Unix(int64(unixTime), 0)
-
-		// Create news item
-		news := models.News{
-			Title:       title,
-			URL:         url,
-			Description: fmt.Sprintf("Score: %d, Comments: %d", int(score), int(descendants)),
-			Source:      "Hacker News",
-			PublishedAt: publishedAt,
-			Score:       int(score),
-			Comments:    int(descendants),
-		}
-
-		newsList = append(newsList, news)
-	}
-
-	// If all stories were skipped, return an error
-	if len(newsList) == 0 && len(skippedStories) > 0 {
-		return nil, fmt.Errorf("failed to fetch any Hacker News stories, skipped: %v", skippedStories)
-	}
-
-	// Sort by score
-	sort.Slice(newsList, func(i, j int) bool {
-		return newsList[i].Score > newsList[j].Score
-	})
-
-	return newsList, nil
+// sourceResult is the outcome of fetching a single source
+type sourceResult struct {
+	news     []models.News
+	err      error
+	duration time.Duration
 }
 
-// getRedditToken gets an OAuth2 access token from Reddit API
-func (s *NewsService) getRedditToken() (string, error) {
-	// Check if we have app credentials
-	if s.redditAppConfig.AppID == "" || s.redditAppConfig.AppSecret == "" {
-		return "", fmt.Errorf("reddit app credentials not configured")
-	}
-
-	// Prepare form data for OAuth2 client credentials grant
-	data := url.Values{}
-	data.Set("grant_type", "client_credentials")
-
-	// Create basic auth header
-	auth := base64.StdEncoding.EncodeToString([]byte(s.redditAppConfig.AppID + ":" + s.redditAppConfig.AppSecret))
-	headers := map[string]string{
-		"Authorization": "Basic " + auth,
-		"User-Agent":    "github:" + s.redditAppConfig.AppID + ":v0.1 by u/ducminhgd",
-	}
-
-	// Make POST request to get access token
-	body, err := s.httpClient.PostForm("https://www.reddit.com/api/v1/access_token", data, headers)
+// NewNewsService creates a new NewsService
+func NewNewsService(sources []models.Source) *NewsService {
+	fetchConfig, err := config.LoadFetchConfig()
 	if err != nil {
-		return "", fmt.Errorf("failed to get Reddit access token: %w", err)
-	}
-
-	// Parse response
-	var tokenResponse struct {
-		AccessToken string `json:"access_token"`
-		TokenType   string `json:"token_type"`
-		ExpiresIn   int    `json:"expires_in"`
-		Scope       string `json:"scope"`
+		fmt.Printf("WARNING: invalid fetch configuration, using defaults: %v\n", err)
+		fetchConfig = &config.FetchConfig{
+			Concurrency:   config.DefaultFetchConcurrency,
+			SourceTimeout: config.DefaultSourceTimeout,
+		}
 	}
 
-	if err := json.Unmarshal(body, &tokenResponse); err != nil {
-		return "", fmt.Errorf("failed to parse Reddit token response: %w", err)
+	retryPolicy := http.DefaultRetryPolicy()
+	if fetchConfig.RetryAttempts > 0 {
+		retryPolicy.MaxAttempts = fetchConfig.RetryAttempts
 	}
-
-	if tokenResponse.AccessToken == "" {
-		return "", fmt.Errorf("no access token received from Reddit API")
+	if fetchConfig.RetryBudget > 0 {
+		retryPolicy.Budget = fetchConfig.RetryBudget
 	}
 
-	return tokenResponse.AccessToken, nil
-}
-
-// fetchReddit fetches news from Reddit using OAuth2 authentication
-func (s *NewsService) fetchReddit(source models.Source) ([]models.News, error) {
-	// Construct URL
-	subreddit := source.SubSource
-	if subreddit == "" {
-		return nil, fmt.Errorf("subreddit is required for Reddit source")
+	clientOptions := []http.Option{http.WithRetryPolicy(retryPolicy)}
+	if fetchConfig.CacheDir != "" {
+		cache, err := http.NewCache(fetchConfig.CacheDir)
+		if err != nil {
+			fmt.Printf("WARNING: HTTP cache disabled: %v\n", err)
+		} else {
+			clientOptions = append(clientOptions, http.WithCache(cache))
+		}
 	}
 
-	// Get OAuth2 access token
-	accessToken, err := s.getRedditToken()
-	if err != nil {
-		// Fall back to unauthenticated request if OAuth fails
-		fmt.Printf("WARNING: failed to get Reddit OAuth token, falling back to unauthenticated request: %v\n", err)
-		return s.fetchRedditUnauthenticated(source)
+	service := &NewsService{
+		httpClient:    http.NewClient(clientOptions...),
+		sources:       sources,
+		concurrency:   fetchConfig.Concurrency,
+		sourceTimeout: fetchConfig.SourceTimeout,
+		fetchers:      make(map[string]repositories.Fetcher),
 	}
 
-	// Use OAuth2 API endpoint
-	redditURL := fmt.Sprintf("https://oauth.reddit.com/r/%s/hot?limit=%d", url.PathEscape(subreddit), source.Limit)
-
-	// Fetch data with OAuth2 Bearer token
-	headers := map[string]string{
-		"Authorization": "Bearer " + accessToken,
-		"User-Agent":    "github:" + s.redditAppConfig.AppID + ":v0.1 by u/ducminhgd",
-	}
-	body, err := s.httpClient.GetWithHeaders(redditURL, headers)
+	historyConfig, err := config.LoadHistoryConfig()
 	if err != nil {
-		// If OAuth request fails, fall back to unauthenticated request
-		fmt.Printf("WARNING: OAuth Reddit request failed, falling back to unauthenticated request: %v\n", err)
-		return s.fetchRedditUnauthenticated(source)
-	}
-
-	// Parse response
-	var response struct {
-		Data struct {
-			Children []struct {
-				Data struct {
-					Title       string  `json:"title"`
-					URL         string  `json:"url"`
-					Permalink   string  `json:"permalink"`
-					Score       int     `json:"score"`
-					NumComments int     `json:"num_comments"`
-					Created     float64 `json:"created_utc"`
-					Selftext    string  `json:"selftext"`
-				} `json:"data"`
-			} `json:"children"`
-		} `json:"data"`
-	}
-
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse Reddit response: %w", err)
+		fmt.Printf("WARNING: history disabled: %v\n", err)
+	} else if historyConfig.File != "" {
+		history, err := repositories.NewHistoryStore(historyConfig.Backend, historyConfig.File)
+		if err != nil {
+			fmt.Printf("WARNING: history disabled: %v\n", err)
+		} else {
+			service.history = history
+			service.historyWindow = historyConfig.Window
+			service.historyMode = historyConfig.Mode
+		}
 	}
 
-	// Extract posts
-	var newsList []models.News
-	var skippedPosts []string
-
-	for _, child := range response.Data.Children {
-		post := child.Data
-
-		// Skip stickied posts or announcements
-		if strings.HasPrefix(strings.ToLower(post.Title), "[announcement]") {
-			continue
-		}
+	return service
+}
 
-		// Skip posts with empty titles (shouldn't happen, but just in case)
-		if post.Title == "" {
-			fmt.Printf("WARNING: skipping Reddit post with empty title in r/%s\n", subreddit)
-			skippedPosts = append(skippedPosts, "unknown post")
+// FetchAllNews fetches news from all sources in parallel, at most s.concurrency sources at a time,
+// and returns them as a digest with one section per source in the configured order
+// If a source can't be crawled, its section is marked as failed and a warning will be logged
+// If ctx is done before all sources are fetched, the sources not fetched yet are marked as failed
+// with the context error, and the sources already fetched are kept
+func (s *NewsService) FetchAllNews(ctx context.Context) (*models.Digest, error) {
+	digest := &models.Digest{StartedAt: time.Now()}
+	results := make([]sourceResult, len(s.sources))
+
+	workers := s.concurrency
+	if workers <= 0 {
+		workers = config.DefaultFetchConcurrency
+	}
+	workers = min(workers, len(s.sources))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				start := time.Now()
+				news, err := s.fetchWithTimeout(ctx, s.sources[i])
+				results[i] = sourceResult{news: news, err: err, duration: time.Since(start)}
+			}
+		}()
+	}
+
+	for i := range s.sources {
+		// Sources not started yet are not fetched once the run is interrupted
+		if err := ctx.Err(); err != nil {
+			results[i] = sourceResult{err: fmt.Errorf("not fetched: %w", err)}
 			continue
 		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	digest.FinishedAt = time.Now()
 
-		// Create URL (use permalink if URL is empty)
-		postURL := post.URL
-		if postURL == "" || strings.HasPrefix(postURL, "/r/") {
-			postURL = fmt.Sprintf("https://www.reddit.com%s", post.Permalink)
-		}
-
-		// Create description
-		description := post.Selftext
-		if len(description) > 100 {
-			description = description[:100] + "..."
+	for i, source := range s.sources {
+		section := models.Section{
+			Name:     source.Name,
+			Title:    source.Title,
+			Items:    results[i].news,
+			Status:   models.SectionOK,
+			Duration: results[i].duration,
 		}
-		if description == "" {
-			description = fmt.Sprintf("Score: %d, Comments: %d", post.Score, post.NumComments)
+		if section.Title == "" {
+			section.Title = source.Name
 		}
 
-		// Create news item
-		news := models.News{
-			Title:       post.Title,
-			URL:         postURL,
-			Description: description,
-			Source:      "Reddit",
-			SubSource:   subreddit,
-			PublishedAt: time.Unix(int64(post.Created), 0),
-			Score:       post.Score,
-			Comments:    post.NumComments,
+		switch err := results[i].err; {
+		case err != nil:
+			// Log warning and continue with other sources
+			fmt.Printf("WARNING: failed to fetch news from %s: %v\n", source.Name, err)
+			section.Items = nil
+			section.Status = models.SectionFailed
+			section.Error = err.Error()
+		case len(section.Items) == 0:
+			section.Status = models.SectionEmpty
 		}
 
-		newsList = append(newsList, news)
+		digest.Sections = append(digest.Sections, section)
 	}
 
-	// If all posts were skipped, return an error
-	if len(newsList) == 0 && len(skippedPosts) > 0 {
-		return nil, fmt.Errorf("failed to fetch any Reddit posts from r/%s, skipped: %v", subreddit, skippedPosts)
+	// If all sources were skipped, return an error
+	if skippedSources := digest.FailedSections(); len(skippedSources) > 0 && len(skippedSources) == len(digest.Sections) {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("fetching news was interrupted: %w", err)
+		}
+		return nil, fmt.Errorf("failed to fetch news from any source, skipped: %v", skippedSources)
 	}
 
-	// Sort by score
-	sort.Slice(newsList, func(i, j int) bool {
-		return newsList[i].Score > newsList[j].Score
-	})
-
-	return newsList, nil
+	return digest, nil
 }
 
-// fetchRedditUnauthenticated fetches news from Reddit without authentication (fallback method)
-func (s *NewsService) fetchRedditUnauthenticated(source models.Source) ([]models.News, error) {
-	// Construct URL
-	subreddit := source.SubSource
-	if subreddit == "" {
-		return nil, fmt.Errorf("subreddit is required for Reddit source")
+// fetchWithTimeout fetches news from a source, giving up once the source deadline has passed
+func (s *NewsService) fetchWithTimeout(ctx context.Context, source models.Source) ([]models.News, error) {
+	timeout := source.Timeout
+	if timeout <= 0 {
+		timeout = s.sourceTimeout
+	}
+	if timeout <= 0 {
+		timeout = config.DefaultSourceTimeout
 	}
 
-	redditURL := fmt.Sprintf("%s/r/%s/hot.json?limit=%d", source.URL, url.PathEscape(subreddit), source.Limit)
+	sourceCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	// Fetch data with custom User-Agent header
-	headers := map[string]string{
-		"User-Agent": "github:" + s.redditAppConfig.AppID + ":v0.1 by u/ducminhgd",
-	}
-	body, err := s.httpClient.GetWithHeaders(redditURL, headers)
-	if err != nil {
-		// If we get a 403 Forbidden error, it's likely due to Reddit's API restrictions
-		// This is common in CI/CD environments like GitHub Actions
-		if strings.Contains(err.Error(), "403") {
-			return nil, fmt.Errorf("reddit API access forbidden (403) - this is common in CI/CD environments: %w", err)
+	if s.history != nil {
+		if lastRun, ok := s.history.LastRun(source.Name); ok {
+			source.Since = lastRun
 		}
-		return nil, fmt.Errorf("failed to fetch Reddit data: %w", err)
-	}
-
-	// Parse response
-	var response struct {
-		Data struct {
-			Children []struct {
-				Data struct {
-					Title       string  `json:"title"`
-					URL         string  `json:"url"`
-					Permalink   string  `json:"permalink"`
-					Score       int     `json:"score"`
-					NumComments int     `json:"num_comments"`
-					Created     float64 `json:"created_utc"`
-					Selftext    string  `json:"selftext"`
-				} `json:"data"`
-			} `json:"children"`
-		} `json:"data"`
 	}
 
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("failed to parse Reddit response: %w", err)
+	news, err := s.FetchNewsBySource(sourceCtx, source)
+	if err != nil && ctx.Err() == nil && errors.Is(sourceCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s: %w", timeout, err)
 	}
 
-	// Extract posts
-	var newsList []models.News
-	var skippedPosts []string
-
-	for _, child := range response.Data.Children {
-		post := child.Data
-
-		// Skip stickied posts or announcements
-		if strings.HasPrefix(strings.ToLower(post.Title), "[announcement]") {
-			continue
-		}
+	return news, err
+}
 
-		// Skip posts with empty titles (shouldn't happen, but just in case)
-		if post.Title == "" {
-			fmt.Printf("WARNING: skipping Reddit post with empty title in r/%s\n", subreddit)
-			skippedPosts = append(skippedPosts, "unknown post")
-			continue
-		}
+// FetchNewsBySource fetches news from a specific source
+func (s *NewsService) FetchNewsBySource(ctx context.Context, source models.Source) ([]models.News, error) {
+	fetcher, err := s.fetcherFor(source.Type)
+	if err != nil {
+		return nil, err
+	}
 
-		// Create URL (use permalink if URL is empty)
-		postURL := post.URL
-		if postURL == "" || strings.HasPrefix(postURL, "/r/") {
-			postURL = fmt.Sprintf("https://www.reddit.com%s", post.Permalink)
-		}
+	return fetcher.Fetch(ctx, source)
+}
 
-		// Create description
-		description := post.Selftext
-		if len(description) > 100 {
-			description = description[:100] + "..."
-		}
-		if description == "" {
-			description = fmt.Sprintf("Score: %d, Comments: %d", post.Score, post.NumComments)
-		}
+// fetcherFor returns the fetcher registered for a source type, creating it on first use
+func (s *NewsService) fetcherFor(sourceType string) (repositories.Fetcher, error) {
+	key := strings.ToLower(sourceType)
 
-		// Create news item
-		news := models.News{
-			Title:       post.Title,
-			URL:         postURL,
-			Description: description,
-			Source:      "Reddit",
-			SubSource:   subreddit,
-			PublishedAt: time.Unix(int64(post.Created), 0),
-			Score:       post.Score,
-			Comments:    post.NumComments,
-		}
+	s.fetchersMu.Lock()
+	defer s.fetchersMu.Unlock()
 
-		newsList = append(newsList, news)
+	if fetcher, ok := s.fetchers[key]; ok {
+		return fetcher, nil
 	}
 
-	// If all posts were skipped, return an error
-	if len(newsList) == 0 && len(skippedPosts) > 0 {
-		return nil, fmt.Errorf("failed to fetch any Reddit posts from r/%s, skipped: %v", subreddit, skippedPosts)
+	fetcher, err := repositories.NewFetcher(key, s.httpClient)
+	if err != nil {
+		return nil, err
 	}
 
-	// Sort by score
-	sort.Slice(newsList, func(i, j int) bool {
-		return newsList[i].Score > newsList[j].Score
-	})
+	if s.fetchers == nil {
+		s.fetchers = make(map[string]repositories.Fetcher)
+	}
+	s.fetchers[key] = fetcher
 
-	return newsList, nil
+	return fetcher, nil
 }
@@ -1,14 +1,20 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/url"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/ducminhgd/gossip-bot/internal/models"
+	"github.com/ducminhgd/gossip-bot/internal/repositories"
+	"github.com/ducminhgd/gossip-bot/pkg/http"
 )
 
 // MockHTTPClient is a mock implementation of the HTTP client for testing
@@ -17,6 +23,32 @@ type MockHTTPClient struct {
 	GetFunc            func(url string) ([]byte, error)
 	GetWithHeadersFunc func(url string, headers map[string]string) ([]byte, error)
 	PostFormFunc       func(url string, data url.Values, headers map[string]string) ([]byte, error)
+	PostJSONFunc       func(url string, payload any, headers map[string]string) ([]byte, error)
+}
+
+// GetContext is a mock implementation of the GetContext method
+func (m *MockHTTPClient) GetContext(ctx context.Context, url string) ([]byte, error) {
+	return m.Get(url)
+}
+
+// GetWithHeadersContext is a mock implementation of the GetWithHeadersContext method
+func (m *MockHTTPClient) GetWithHeadersContext(ctx context.Context, url string, headers map[string]string) ([]byte, error) {
+	return m.GetWithHeaders(url, headers)
+}
+
+// GetJSONContext is a mock implementation of the GetJSONContext method
+func (m *MockHTTPClient) GetJSONContext(ctx context.Context, url string, v any) error {
+	return m.GetJSON(url, v)
+}
+
+// PostFormContext is a mock implementation of the PostFormContext method
+func (m *MockHTTPClient) PostFormContext(ctx context.Context, url string, data url.Values, headers map[string]string) ([]byte, error) {
+	return m.PostForm(url, data, headers)
+}
+
+// PostJSONContext is a mock implementation of the PostJSONContext method
+func (m *MockHTTPClient) PostJSONContext(ctx context.Context, url string, payload any, headers map[string]string) ([]byte, error) {
+	return m.PostJSON(url, payload, headers)
 }
 
 // Get is a mock implementation of the Get method
@@ -51,6 +83,14 @@ func (m *MockHTTPClient) PostForm(url string, data url.Values, headers map[strin
 	return nil, errors.New("PostFormFunc not implemented")
 }
 
+// PostJSON is a mock implementation of the PostJSON method
+func (m *MockHTTPClient) PostJSON(url string, payload any, headers map[string]string) ([]byte, error) {
+	if m.PostJSONFunc != nil {
+		return m.PostJSONFunc(url, payload, headers)
+	}
+	return nil, errors.New("PostJSONFunc not implemented")
+}
+
 // TestNewNewsService tests the NewNewsService function
 func TestNewNewsService(t *testing.T) {
 	// Create test sources
@@ -128,8 +168,8 @@ func TestFetchAllNews_Success(t *testing.T) {
 		GetWithHeadersFunc: func(url string, headers map[string]string) ([]byte, error) {
 			if url == "https://www.reddit.com/r/golang/hot.json?limit=1" {
 				// Verify the User-Agent header is set correctly
-				if userAgent, ok := headers["User-Agent"]; !ok || userAgent != "myredditbot/0.1 by u/ducminhgd" {
-					return nil, errors.New("expected User-Agent header 'myredditbot/0.1 by u/ducminhgd'")
+				if userAgent, ok := headers["User-Agent"]; !ok || !strings.HasSuffix(userAgent, ":v0.1 by u/ducminhgd") {
+					return nil, errors.New("expected User-Agent header 'github:<app id>:v0.1 by u/ducminhgd'")
 				}
 
 				// Mock the Reddit response
@@ -224,56 +264,338 @@ func TestFetchAllNews_Success(t *testing.T) {
 	}
 
 	// Call the method being tested
-	result, err := service.FetchAllNews()
+	result, err := service.FetchAllNews(context.Background())
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
 
-	// Check the results
-	if len(result) != 2 {
-		t.Fatalf("Expected 2 sources in result, got %d", len(result))
+	// Check the results, sections follow the order of the sources
+	if len(result.Sections) != 2 {
+		t.Fatalf("Expected 2 sections in result, got %d", len(result.Sections))
+	}
+	if result.Sections[0].Name != "HackerNews" || result.Sections[1].Name != "RedditGo" {
+		t.Fatalf("Expected sections in source order, got %s and %s", result.Sections[0].Name, result.Sections[1].Name)
+	}
+	if result.StartedAt.IsZero() || result.FinishedAt.Before(result.StartedAt) {
+		t.Errorf("Expected the run timing, got %v to %v", result.StartedAt, result.FinishedAt)
 	}
 
 	// Check HackerNews results
-	hackerNews, ok := result["HackerNews"]
+	hackerNewsSection, ok := result.Section("HackerNews")
 	if !ok {
 		t.Fatal("Expected HackerNews in result")
 	}
+	if hackerNewsSection.Title != "HackerNews" || hackerNewsSection.Status != models.SectionOK {
+		t.Errorf("Expected an ok section titled after the source, got %+v", hackerNewsSection)
+	}
+	hackerNews := hackerNewsSection.Items
 	if len(hackerNews) != 1 {
 		t.Fatalf("Expected 1 HackerNews item, got %d", len(hackerNews))
 	}
 	expectedHackerNews := models.News{
-		Title:       "Test Story",
-		URL:         "https://example.com",
-		Description: "Score: 100, Comments: 10",
-		Source:      "Hacker News",
-		PublishedAt: time.Unix(1625097600, 0),
-		Score:       100,
-		Comments:    10,
+		Title:         "Test Story",
+		URL:           "https://example.com",
+		Description:   "Score: 100, Comments: 10",
+		Source:        "Hacker News",
+		PublishedAt:   time.Unix(1625097600, 0),
+		Score:         100,
+		Comments:      10,
+		ID:            "hackernews:123",
+		Rank:          1,
+		DiscussionURL: "https://news.ycombinator.com/item?id=123",
+	}
+	if hackerNews[0].FetchedAt.IsZero() {
+		t.Error("Expected the HackerNews fetch time to be set")
 	}
+	hackerNews[0].FetchedAt = time.Time{}
 	if !reflect.DeepEqual(hackerNews[0], expectedHackerNews) {
 		t.Fatalf("Expected %+v, got %+v", expectedHackerNews, hackerNews[0])
 	}
 
 	// Check RedditGo results
-	redditNews, ok := result["RedditGo"]
+	redditSection, ok := result.Section("RedditGo")
 	if !ok {
 		t.Fatal("Expected RedditGo in result")
 	}
+	redditNews := redditSection.Items
 	if len(redditNews) != 1 {
 		t.Fatalf("Expected 1 RedditGo item, got %d", len(redditNews))
 	}
 	expectedRedditNews := models.News{
-		Title:       "Test Reddit Post",
-		URL:         "https://example.com/reddit",
-		Description: "Test post content",
-		Source:      "Reddit",
-		SubSource:   "golang",
-		PublishedAt: time.Unix(1625184000, 0),
-		Score:       200,
-		Comments:    20,
+		Title:         "Test Reddit Post",
+		URL:           "https://example.com/reddit",
+		Description:   "Test post content",
+		Source:        "Reddit",
+		SubSource:     "golang",
+		PublishedAt:   time.Unix(1625184000, 0),
+		Score:         200,
+		Comments:      20,
+		ID:            "reddit:/r/golang/comments/123/test",
+		Rank:          1,
+		DiscussionURL: "https://www.reddit.com/r/golang/comments/123/test",
 	}
+	if redditNews[0].FetchedAt.IsZero() {
+		t.Error("Expected the RedditGo fetch time to be set")
+	}
+	redditNews[0].FetchedAt = time.Time{}
 	if !reflect.DeepEqual(redditNews[0], expectedRedditNews) {
 		t.Fatalf("Expected %+v, got %+v", expectedRedditNews, redditNews[0])
 	}
 }
+
+// fakeFetcher is a fetcher that returns canned news items
+type fakeFetcher struct {
+	news []models.News
+	err  error
+}
+
+// Fetch returns the canned news items
+func (f *fakeFetcher) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	return f.news, f.err
+}
+
+// TestFetchNewsBySource_RegisteredFetcher tests that FetchNewsBySource uses the fetcher registered for the source type
+func TestFetchNewsBySource_RegisteredFetcher(t *testing.T) {
+	expected := []models.News{
+		{Title: "Fake Story", URL: "https://example.com/fake", Source: "Fake"},
+	}
+	repositories.Register("fake", func(httpClient http.HTTPClient) repositories.Fetcher {
+		return &fakeFetcher{news: expected}
+	})
+
+	service := &NewsService{
+		httpClient: &MockHTTPClient{},
+	}
+
+	news, err := service.FetchNewsBySource(context.Background(), models.Source{Name: "Fake", Type: "FAKE", Limit: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(news, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, news)
+	}
+}
+
+// TestFetchNewsBySource_UnsupportedType tests that FetchNewsBySource returns an error for unknown source types
+func TestFetchNewsBySource_UnsupportedType(t *testing.T) {
+	service := &NewsService{
+		httpClient: &MockHTTPClient{},
+	}
+
+	_, err := service.FetchNewsBySource(context.Background(), models.Source{Name: "Unknown", Type: "unknown"})
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+	if err.Error() != "unsupported source type: unknown" {
+		t.Errorf("Expected unsupported source type error, got '%s'", err.Error())
+	}
+}
+
+// TestFetchAllNews_SkipsFailedSources tests that failing sources are skipped
+func TestFetchAllNews_SkipsFailedSources(t *testing.T) {
+	repositories.Register("fake-ok", func(httpClient http.HTTPClient) repositories.Fetcher {
+		return &fakeFetcher{news: []models.News{{Title: "OK"}}}
+	})
+	repositories.Register("fake-fail", func(httpClient http.HTTPClient) repositories.Fetcher {
+		return &fakeFetcher{err: errors.New("mock error")}
+	})
+
+	service := &NewsService{
+		httpClient: &MockHTTPClient{},
+		sources: []models.Source{
+			{Name: "Good", Type: "fake-ok"},
+			{Name: "Bad", Type: "fake-fail"},
+		},
+	}
+
+	result, err := service.FetchAllNews(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Sections) != 2 {
+		t.Fatalf("Expected 2 sections in result, got %d", len(result.Sections))
+	}
+	if section, ok := result.Section("Good"); !ok || section.Status != models.SectionOK {
+		t.Fatalf("Expected Good in result, got %+v", section)
+	}
+	if section, ok := result.Section("Bad"); !ok || section.Status != models.SectionFailed || section.Error == "" || section.Items != nil {
+		t.Fatalf("Expected Bad to be marked as failed, got %+v", section)
+	}
+
+	// All sources failing is an error
+	service.sources = service.sources[1:]
+	if _, err := service.FetchAllNews(context.Background()); err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+}
+
+// funcFetcher is a fetcher backed by a function
+type funcFetcher func(ctx context.Context, source models.Source) ([]models.News, error)
+
+// Fetch calls the underlying function
+func (f funcFetcher) Fetch(ctx context.Context, source models.Source) ([]models.News, error) {
+	return f(ctx, source)
+}
+
+// TestFetchAllNews_ConcurrencyLimit tests that no more than the configured number of sources are fetched at once
+func TestFetchAllNews_ConcurrencyLimit(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+
+	repositories.Register("fake-slow", func(httpClient http.HTTPClient) repositories.Fetcher {
+		return funcFetcher(func(ctx context.Context, source models.Source) ([]models.News, error) {
+			mu.Lock()
+			running++
+			maxRunning = max(maxRunning, running)
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return []models.News{{Title: source.Name}}, nil
+		})
+	})
+
+	var sources []models.Source
+	for i := range 6 {
+		sources = append(sources, models.Source{Name: fmt.Sprintf("Source%d", i), Type: "fake-slow"})
+	}
+
+	service := &NewsService{
+		httpClient:  &MockHTTPClient{},
+		sources:     sources,
+		concurrency: 2,
+	}
+
+	result, err := service.FetchAllNews(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(result.Sections) != len(sources) {
+		t.Fatalf("Expected %d sections in result, got %d", len(sources), len(result.Sections))
+	}
+	for i, source := range sources {
+		section := result.Sections[i]
+		if section.Name != source.Name || len(section.Items) != 1 || section.Items[0].Title != source.Name {
+			t.Errorf("Expected section %d to be %s, got %+v", i, source.Name, section)
+		}
+	}
+	if maxRunning > 2 {
+		t.Errorf("Expected at most 2 concurrent fetches, got %d", maxRunning)
+	}
+}
+
+// TestFetchAllNews_SourceTimeout tests that a slow source is skipped once its deadline has passed
+func TestFetchAllNews_SourceTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	repositories.Register("fake-hang", func(httpClient http.HTTPClient) repositories.Fetcher {
+		return funcFetcher(func(ctx context.Context, source models.Source) ([]models.News, error) {
+			select {
+			case <-release:
+				return nil, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		})
+	})
+	repositories.Register("fake-fast", func(httpClient http.HTTPClient) repositories.Fetcher {
+		return &fakeFetcher{news: []models.News{{Title: "Fast"}}}
+	})
+
+	service := &NewsService{
+		httpClient: &MockHTTPClient{},
+		sources: []models.Source{
+			{Name: "Hang", Type: "fake-hang", Timeout: 10 * time.Millisecond},
+			{Name: "Fast", Type: "fake-fast"},
+		},
+		sourceTimeout: time.Minute,
+	}
+
+	start := time.Now()
+	result, err := service.FetchAllNews(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the hanging source to time out quickly, took %s", elapsed)
+	}
+	if section, _ := result.Section("Hang"); section.Status != models.SectionFailed {
+		t.Errorf("Expected Hang to be skipped, got %+v", section)
+	}
+	if section, _ := result.Section("Fast"); section.Status != models.SectionOK {
+		t.Errorf("Expected Fast in result, got %+v", section)
+	}
+}
+
+// TestFetchAllNews_Cancelled tests that FetchAllNews stops once the run context is cancelled
+func TestFetchAllNews_Cancelled(t *testing.T) {
+	repositories.Register("fake-block", func(httpClient http.HTTPClient) repositories.Fetcher {
+		return funcFetcher(func(ctx context.Context, source models.Source) ([]models.News, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	})
+
+	service := &NewsService{
+		httpClient: &MockHTTPClient{},
+		sources: []models.Source{
+			{Name: "Block1", Type: "fake-block"},
+			{Name: "Block2", Type: "fake-block"},
+		},
+		sourceTimeout: time.Minute,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err := service.FetchAllNews(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// TestFetchAllNews_CancelledKeepsFetchedSources tests that the sources fetched before the run
+// context is cancelled are kept, and the others are marked as failed
+func TestFetchAllNews_CancelledKeepsFetchedSources(t *testing.T) {
+	repositories.Register("fake-block", func(httpClient http.HTTPClient) repositories.Fetcher {
+		return funcFetcher(func(ctx context.Context, source models.Source) ([]models.News, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	})
+	repositories.Register("fake-fast", func(httpClient http.HTTPClient) repositories.Fetcher {
+		return &fakeFetcher{news: []models.News{{Title: "Fast"}}}
+	})
+
+	service := &NewsService{
+		httpClient: &MockHTTPClient{},
+		sources: []models.Source{
+			{Name: "Fast", Type: "fake-fast"},
+			{Name: "Block", Type: "fake-block"},
+			{Name: "Queued", Type: "fake-fast"},
+		},
+		concurrency:   2,
+		sourceTimeout: time.Minute,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	result, err := service.FetchAllNews(ctx)
+	if err != nil {
+		t.Fatalf("Expected the fetched sources to be kept, got %v", err)
+	}
+	if section, _ := result.Section("Fast"); section.Status != models.SectionOK || len(section.Items) != 1 {
+		t.Errorf("Expected Fast in result, got %+v", section)
+	}
+	if section, _ := result.Section("Block"); section.Status != models.SectionFailed || !strings.Contains(section.Error, "canceled") {
+		t.Errorf("Expected Block to fail with the context error, got %+v", section)
+	}
+	if len(result.Sections) != 3 {
+		t.Errorf("Expected a section per source, got %+v", result.Sections)
+	}
+}
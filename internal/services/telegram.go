@@ -3,10 +3,14 @@ package services
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strings"
+
+	"github.com/ducminhgd/gossip-bot/internal/models"
 )
 
 const (
@@ -65,3 +69,29 @@ func (s *TelegramService) SendMessage(message string, chat_id int64, thread_id i
 
 	return nil
 }
+
+// SendDigest sends one message per non-empty section of the digest, in the digest order.
+// A section that fails to send does not stop the others, the errors are returned together.
+func (s *TelegramService) SendDigest(digest *models.Digest, chat_id int64, thread_id int64) error {
+	var errs []error
+	for _, section := range digest.Sections {
+		if len(section.Items) == 0 {
+			continue
+		}
+
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("__**[%s] %s**__\n\n", digest.Date(), section.Title))
+
+		// Add news items - only titles, no descriptions
+		for i, news := range section.Items {
+			sb.WriteString(fmt.Sprintf("%d. [%s](%s)%s%s\n", i+1, news.Title, news.URL, alsoDiscussedOn(news), previouslySent(news)))
+		}
+
+		sb.WriteString("\n")
+		if err := s.SendMessage(sb.String(), chat_id, thread_id, TELEGRAM_PARSE_MODE_MARKDOWNV2); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send %s: %w", section.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
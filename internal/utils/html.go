@@ -0,0 +1,154 @@
+// Package utils contains helpers shared by the repositories and services
+package utils
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// blockTags are the elements that separate words, so their tags are replaced by a space
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true, "dd": true,
+	"div": true, "dl": true, "dt": true, "figcaption": true, "figure": true, "footer": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hr": true, "li": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true,
+	"section": true, "table": true, "td": true, "th": true, "tr": true, "ul": true,
+}
+
+// rawTextTags are the elements whose content is not text, such as scripts
+var rawTextTags = map[string]bool{
+	"script": true, "style": true, "template": true,
+}
+
+// HTMLToText converts an HTML fragment into plain text.
+// Tags and comments are removed, scripts and styles are dropped with their content,
+// every entity is decoded and whitespace is collapsed into single spaces.
+func HTMLToText(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+
+	for i := 0; i < len(s); {
+		// A "<" not followed by a tag name is text, as in "a < b"
+		if s[i] != '<' || !isTagStart(s, i+1) {
+			sb.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(s[i:], "<!--") {
+			end := strings.Index(s[i+4:], "-->")
+			if end < 0 {
+				break
+			}
+			i += 4 + end + 3
+			continue
+		}
+
+		end := tagEnd(s, i+1)
+		if end < 0 {
+			// An unterminated tag is the end of a truncated fragment
+			break
+		}
+
+		name, closing := tagName(s[i+1 : end])
+		i = end + 1
+
+		if rawTextTags[name] && !closing {
+			i = skipRawText(s, i, name)
+			continue
+		}
+		if blockTags[name] {
+			sb.WriteByte(' ')
+		}
+	}
+
+	return strings.Join(strings.Fields(html.UnescapeString(sb.String())), " ")
+}
+
+// Truncate shortens text to at most maxRunes runes followed by "...".
+// The text is cut at the last word boundary when there is one, and never inside a rune.
+func Truncate(text string, maxRunes int) string {
+	if maxRunes <= 0 || utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := string(runes[:maxRunes])
+
+	// Drop the partial word, unless the cut falls right before a new word
+	if !unicode.IsSpace(runes[maxRunes]) {
+		if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > 0 {
+			cut = cut[:i]
+		}
+	}
+
+	cut = strings.TrimRightFunc(cut, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+
+	return cut + "..."
+}
+
+// isTagStart reports whether s[i:] starts a tag name, a closing tag, a comment or a declaration
+func isTagStart(s string, i int) bool {
+	if i >= len(s) {
+		return false
+	}
+	c := s[i]
+	return c == '/' || c == '!' || c == '?' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// tagEnd returns the index of the ">" closing the tag starting at s[i:], skipping quoted attribute values
+func tagEnd(s string, i int) int {
+	var quote byte
+	for ; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+// tagName returns the lowercase name of a tag from its content between "<" and ">"
+func tagName(tag string) (string, bool) {
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimPrefix(tag, "/")
+
+	end := strings.IndexFunc(tag, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '/'
+	})
+	if end >= 0 {
+		tag = tag[:end]
+	}
+
+	return strings.ToLower(tag), closing
+}
+
+// skipRawText returns the index following the closing tag of a raw text element starting at s[i:]
+func skipRawText(s string, i int, name string) int {
+	// Search case-insensitively without lowering s, which could shift the byte offsets
+	needle := "</" + name
+	for ; i+len(needle) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(needle)], needle) {
+			break
+		}
+	}
+	if i+len(needle) > len(s) {
+		return len(s)
+	}
+
+	end := tagEnd(s, i+len(needle))
+	if end < 0 {
+		return len(s)
+	}
+	return end + 1
+}
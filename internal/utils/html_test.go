@@ -0,0 +1,56 @@
+package utils
+
+import "testing"
+
+func TestHTMLToText(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"<p>Simple text</p>", "Simple text"},
+		{"Text with &amp; entities", "Text with & entities"},
+		{"<br/>Line break", "Line break"},
+		{"First<br>second<p>third</p>", "First second third"},
+		{`Read <a href="https://example.com/?a=1&amp;b=2" title="a > b">the post</a>.`, "Read the post."},
+		{`<img src="x.png" alt="diagram"><b>Bold</b>face`, "Boldface"},
+		{"Numeric &#8220;quotes&#8221; &#x2014; and&nbsp;spaces", "Numeric “quotes” — and spaces"},
+		{"a < b and b > c", "a < b and b > c"},
+		{"&lt;script&gt; stays text", "<script> stays text"},
+		{"Before<script>alert('<p>')</script>after<STYLE>p{}</STYLE>!", "Beforeafter!"},
+		{"Hidden <!-- a <b>comment</b> --> text", "Hidden text"},
+		{"  Lots\n\n of\t whitespace  ", "Lots of whitespace"},
+		{"Cut in the middle of a <a href=", "Cut in the middle of a"},
+		{"", ""},
+	}
+
+	for _, tc := range testCases {
+		if result := HTMLToText(tc.input); result != tc.expected {
+			t.Errorf("HTMLToText(%q): expected %q, got %q", tc.input, tc.expected, result)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	testCases := []struct {
+		input    string
+		maxRunes int
+		expected string
+	}{
+		{"Short text", 20, "Short text"},
+		{"Exactly ten", 11, "Exactly ten"},
+		{"The quick brown fox jumps", 12, "The quick..."},
+		{"The quick brown fox jumps", 15, "The quick brown..."},
+		{"The quick, brown fox", 11, "The quick..."},
+		{"Supercalifragilistic", 5, "Super..."},
+		{"Xin chào thế giới", 11, "Xin chào..."},
+		{"Xin chào thế giới", 12, "Xin chào thế..."},
+		{"日本語のテキスト", 3, "日本語..."},
+		{"Anything", 0, "Anything"},
+	}
+
+	for _, tc := range testCases {
+		if result := Truncate(tc.input, tc.maxRunes); result != tc.expected {
+			t.Errorf("Truncate(%q, %d): expected %q, got %q", tc.input, tc.maxRunes, tc.expected, result)
+		}
+	}
+}
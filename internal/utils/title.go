@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are the function words that don't tell two titles apart, including the pieces
+// of contractions such as "what's" or "don't"
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "i": true, "in": true, "is": true, "it": true, "its": true,
+	"of": true, "on": true, "or": true, "s": true, "t": true, "the": true, "this": true, "to": true,
+	"with": true, "you": true, "your": true,
+}
+
+// TitleTokens returns the distinct words of a title that tell it apart from other titles.
+// Words are lower-cased and reduced to a common stem, stop words are dropped, and version
+// numbers and names such as "1.23", "C++" or "C#" are kept whole,
+// e.g. "Go 1.23 is Released!" gives ["go", "1.23", "release"].
+func TitleTokens(title string) []string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '+' && r != '#'
+	})
+
+	seen := make(map[string]bool, len(words))
+	var tokens []string
+	for _, word := range words {
+		word = strings.Trim(word, ".")
+		if word == "" || stopWords[word] {
+			continue
+		}
+		word = stem(word)
+		if !seen[word] {
+			seen[word] = true
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// stem reduces a word to its singular, and a past participle such as "released" to "release".
+// Only endings that rarely turn a word into another word are removed, e.g. "news" and "bugs"
+// are kept as they are, and "testing" is not reduced to "test".
+func stem(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) >= 7:
+		return strings.TrimSuffix(word, "ies") + "y"
+	case strings.HasSuffix(word, "xes") || strings.HasSuffix(word, "ches") || strings.HasSuffix(word, "shes"):
+		return strings.TrimSuffix(word, "es")
+	case strings.HasSuffix(word, "s") && len(word) >= 5 &&
+		!strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is") && !strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "s")
+	case strings.HasSuffix(word, "ed") && len(word) >= 6:
+		return strings.TrimSuffix(word, "d")
+	}
+	return word
+}
+
+// TitleSimilarity returns the share of words two titles have in common, from 0 for titles without
+// a common word to 1 for titles with the same words. a and b are the tokens of the titles as
+// returned by TitleTokens. Titles that both carry numbers, such as versions, but no common number
+// are about different things and have a similarity of 0, e.g. "Go 1.22 release notes" and
+// "Go 1.23 release notes".
+func TitleSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	words := make(map[string]bool, len(a))
+	numbersA := false
+	for _, word := range a {
+		words[word] = true
+		numbersA = numbersA || hasDigit(word)
+	}
+
+	common, numbersB, commonNumbers := 0, false, false
+	for _, word := range b {
+		isNumber := hasDigit(word)
+		numbersB = numbersB || isNumber
+		if words[word] {
+			common++
+			commonNumbers = commonNumbers || isNumber
+		}
+	}
+	if numbersA && numbersB && !commonNumbers {
+		return 0
+	}
+
+	// Jaccard index of the two sets of words
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// hasDigit reports whether a word contains a digit, as in "1.23" or "k8s"
+func hasDigit(word string) bool {
+	return strings.IndexFunc(word, unicode.IsDigit) >= 0
+}
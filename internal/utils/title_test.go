@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTitleTokens(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []string
+	}{
+		{"Go 1.23 is Released!", []string{"go", "1.23", "release"}},
+		{"Go 1.23 Release Notes", []string{"go", "1.23", "release", "note"}},
+		{"Show HN: A C++ and C# linter, written in Go.", []string{"show", "hn", "c++", "c#", "linter", "written", "go"}},
+		{"What's new in Go: how and why", []string{"what", "new", "go", "how", "why"}},
+		{"Testing the tests: tested", []string{"testing", "test", "teste"}},
+		{"News about bugs, libraries, series and fixes", []string{"news", "about", "bugs", "library", "series", "fix"}},
+		{"The end...", []string{"end"}},
+		{"Обновление Go", []string{"обновление", "go"}},
+		{"", nil},
+	}
+
+	for _, tc := range testCases {
+		if result := TitleTokens(tc.input); !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("TitleTokens(%q): expected %q, got %q", tc.input, tc.expected, result)
+		}
+	}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected float64
+	}{
+		{"Go 1.23 is released", "Go 1.23 is released", 1},
+		{"Go 1.23 is released", "Release of Go 1.23", 1},
+		{"Go 1.23 is released", "Go 1.23 Release Notes", 0.75},
+		{"Go 1.23 is released", "Go released", 2.0 / 3},
+		{"Go 1.23 is released", "Weekly thread", 0},
+		{"Go 1.23 is released", "", 0},
+
+		// Different versions are different stories
+		{"Go 1.22 release notes", "Go 1.23 release notes", 0},
+		{"Go 1.23 is released", "Rust 1.80 is released", 0},
+
+		// Question words and "new" carry meaning
+		{"What's new in Go", "What's new in Rust", 0.5},
+		{"What's new in Go 1.23", "What's new in Rust 1.80", 0},
+		{"How I test Go code", "Why I don't test Go code", 0.5},
+	}
+
+	for _, tc := range testCases {
+		if result := TitleSimilarity(TitleTokens(tc.a), TitleTokens(tc.b)); result != tc.expected {
+			t.Errorf("TitleSimilarity(%q, %q): expected %v, got %v", tc.a, tc.b, tc.expected, result)
+		}
+	}
+}
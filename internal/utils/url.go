@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// mobileHostPrefixes are the host labels of the mobile version of a site
+var mobileHostPrefixes = []string{"m.", "mobile."}
+
+// trackingParams are the query parameters that only track where a reader came from
+var trackingParams = map[string]bool{
+	"ref": true, "ref_src": true, "fbclid": true, "gclid": true,
+}
+
+// CanonicalURL returns a form of a link shared by its variants, so that the same article
+// posted to several sources can be recognised, e.g. "http://www.example.com/post/?utm_source=hn"
+// and "https://m.example.com/post#comments" are both "https://example.com/post".
+// The scheme is always https, the www host and the mobile host of a domain without other subdomains
+// are replaced by the domain, pages of the AMP cache are replaced by the page they mirror, tracking
+// and AMP parameters, fragments and trailing slashes are dropped and the remaining query parameters
+// are sorted. A link that is not an absolute http(s) URL is only trimmed.
+func CanonicalURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return rawURL
+	}
+
+	fromAMPCache := false
+	if unwrapped, ok := unwrapAMPCache(u); ok {
+		u, fromAMPCache = unwrapped, true
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if rest, ok := strings.CutPrefix(host, "www."); ok && strings.Contains(rest, ".") {
+		host = rest
+	}
+	for _, prefix := range mobileHostPrefixes {
+		// m.example.com is the mobile site of example.com, m.blog.example.com is left alone
+		if rest, ok := strings.CutPrefix(host, prefix); ok && strings.Count(rest, ".") == 1 {
+			host = rest
+		}
+	}
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	path := strings.TrimRight(u.EscapedPath(), "/")
+	if fromAMPCache {
+		// The AMP cache serves the AMP version of a page, which usually lives under /amp
+		path = strings.TrimSuffix(path, "/amp")
+		if rest, ok := strings.CutPrefix(path, "/amp/"); ok {
+			path = "/" + rest
+		}
+	}
+
+	query := u.Query()
+	for name, values := range query {
+		lowerName := strings.ToLower(name)
+		if strings.HasPrefix(lowerName, "utm_") || trackingParams[lowerName] || lowerName == "amp" ||
+			(lowerName == "outputtype" && len(values) == 1 && strings.EqualFold(values[0], "amp")) {
+			query.Del(name)
+		}
+	}
+
+	canonical := "https://" + host + path
+	if encoded := query.Encode(); encoded != "" {
+		canonical += "?" + encoded
+	}
+	return canonical
+}
+
+// unwrapAMPCache returns the page served by the Google AMP cache or viewer, e.g.
+// https://example-com.cdn.ampproject.org/c/s/example.com/post or https://www.google.com/amp/s/example.com/post
+func unwrapAMPCache(u *url.URL) (*url.URL, bool) {
+	host := strings.ToLower(u.Hostname())
+
+	var rest string
+	switch {
+	case strings.HasSuffix(host, ".cdn.ampproject.org"):
+		rest = strings.TrimPrefix(u.Path, "/c")
+		rest = strings.TrimPrefix(rest, "/v")
+	case host == "www.google.com" || host == "google.com":
+		rest = strings.TrimPrefix(u.Path, "/amp")
+		if rest == u.Path {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	scheme := "http"
+	if secure, ok := strings.CutPrefix(rest, "/s/"); ok {
+		scheme, rest = "https", secure
+	} else {
+		rest = strings.TrimPrefix(rest, "/")
+	}
+
+	target, err := url.Parse(scheme + "://" + rest)
+	if err != nil || target.Host == "" {
+		return nil, false
+	}
+	target.RawQuery = u.RawQuery
+	return target, true
+}
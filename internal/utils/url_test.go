@@ -0,0 +1,40 @@
+package utils
+
+import "testing"
+
+func TestCanonicalURL(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"https://example.com/post", "https://example.com/post"},
+		{"http://www.Example.com/post/", "https://example.com/post"},
+		{"https://m.example.com/post#comments", "https://example.com/post"},
+		{"https://mobile.twitter.com/golang", "https://twitter.com/golang"},
+		{"https://example.com:443/post?utm_source=hn&utm_medium=social&ref=reddit", "https://example.com/post"},
+		{"https://example.com/post?page=2&id=1&fbclid=abc", "https://example.com/post?id=1&page=2"},
+		{"https://news.ycombinator.com/item?id=8863", "https://news.ycombinator.com/item?id=8863"},
+		{"https://m.blog.example.com/post", "https://m.blog.example.com/post"},
+		{"https://example.com/post?amp=1", "https://example.com/post"},
+		{"https://example.com/post?outputType=amp", "https://example.com/post"},
+		{"https://example-com.cdn.ampproject.org/c/s/example.com/post/amp", "https://example.com/post"},
+		{"https://www.google.com/amp/s/www.example.com/amp/post", "https://example.com/post"},
+		// Only the pages of the AMP cache lose their /amp path
+		{"https://github.com/golang/amp", "https://github.com/golang/amp"},
+		{"https://example.com/post/amp/", "https://example.com/post/amp"},
+		{"https://example.com/amp/post", "https://example.com/amp/post"},
+		{"https://amp.example.com/post", "https://amp.example.com/post"},
+		{"https://www.google.com/search?q=go", "https://google.com/search?q=go"},
+		{"http://localhost:8080/", "https://localhost:8080"},
+		{"https://www.com/", "https://www.com"},
+		{"  /relative/path ", "/relative/path"},
+		{"mailto:gopher@example.com", "mailto:gopher@example.com"},
+		{"", ""},
+	}
+
+	for _, tc := range testCases {
+		if result := CanonicalURL(tc.input); result != tc.expected {
+			t.Errorf("CanonicalURL(%q): expected %q, got %q", tc.input, tc.expected, result)
+		}
+	}
+}
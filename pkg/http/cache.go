@@ -0,0 +1,114 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache stores GET responses on disk together with their ETag and Last-Modified validators,
+// so that unchanged resources are revalidated with a conditional GET instead of downloaded again
+type Cache struct {
+	dir string
+}
+
+// cacheEntry is a cached response, stored as one JSON file per URL
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Body         []byte    `json:"body"`
+	StoredAt     time.Time `json:"stored_at"`
+}
+
+// NewCache creates a Cache storing its entries in dir, creating the directory if needed
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return &Cache{dir: dir}, nil
+}
+
+// WithCache makes the client revalidate GET requests against the given cache
+func WithCache(cache *Cache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// path returns the file holding the cache entry for url
+func (c *Cache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load returns the cache entry for url, if any
+func (c *Cache) load(url string) (*cacheEntry, bool) {
+	data, err := os.ReadFile(c.path(url))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != url {
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+// store saves the response body for url if the response carries a validator
+func (c *Cache) store(url string, header http.Header, body []byte) error {
+	entry := cacheEntry{
+		URL:          url,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		Body:         body,
+		StoredAt:     time.Now().UTC(),
+	}
+	if entry.ETag == "" && entry.LastModified == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	// Write to a temporary file first so concurrent readers never see a partial entry
+	tmp, err := os.CreateTemp(c.dir, "entry-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path(url)); err != nil {
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+
+	return nil
+}
+
+// setValidators adds the conditional request headers for a cached entry
+func (e *cacheEntry) setValidators(req *http.Request) {
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
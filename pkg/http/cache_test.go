@@ -0,0 +1,97 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_CacheRevalidatesWithETag(t *testing.T) {
+	var fullResponses atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fullResponses.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("feed"))
+	}))
+	defer server.Close()
+
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	client := NewClient(WithHostLimiter(nil), WithCache(cache))
+
+	for i := range 3 {
+		body, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Expected no error on request %d, got %v", i+1, err)
+		}
+		if string(body) != "feed" {
+			t.Errorf("Expected body 'feed' on request %d, got '%s'", i+1, body)
+		}
+	}
+
+	if fullResponses.Load() != 1 {
+		t.Errorf("Expected 1 full response, got %d", fullResponses.Load())
+	}
+}
+
+func TestClient_CacheRevalidatesWithLastModified(t *testing.T) {
+	const lastModified = "Fri, 04 Jul 2025 00:00:00 GMT"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Last-Modified", lastModified)
+		_, _ = w.Write([]byte("feed"))
+	}))
+	defer server.Close()
+
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A fresh client simulates a rerun of the bot reusing the on-disk cache
+	for i := range 2 {
+		body, err := NewClient(WithHostLimiter(nil), WithCache(cache)).Get(server.URL)
+		if err != nil {
+			t.Fatalf("Expected no error on run %d, got %v", i+1, err)
+		}
+		if string(body) != "feed" {
+			t.Errorf("Expected body 'feed' on run %d, got '%s'", i+1, body)
+		}
+	}
+}
+
+func TestClient_CacheSkipsResponsesWithoutValidators(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("fresh"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cache, err := NewCache(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err := NewClient(WithHostLimiter(nil), WithCache(cache)).Get(server.URL); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no cache entries, got %d", len(entries))
+	}
+}
@@ -1,110 +1,117 @@
 package http
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"math/rand"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 )
 
-// We'll use a package-level random source for jitter calculations
-// As of Go 1.20, there's no need to seed the global rand source
-var rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+// defaultUserAgent is sent with every request unless overridden by a custom header
+const defaultUserAgent = "GossipBot/1.0 (https://github.com/ducminhgd/gossip-bot; contact@example.com)"
 
 // HTTPClient defines the interface for HTTP clients
+// The Context variants abort the request, including pending retries, once ctx is done.
 type HTTPClient interface {
 	Get(url string) ([]byte, error)
 	GetWithHeaders(url string, headers map[string]string) ([]byte, error)
 	GetJSON(url string, v any) error
 	PostForm(url string, data url.Values, headers map[string]string) ([]byte, error)
+	PostJSON(url string, payload any, headers map[string]string) ([]byte, error)
+
+	GetContext(ctx context.Context, url string) ([]byte, error)
+	GetWithHeadersContext(ctx context.Context, url string, headers map[string]string) ([]byte, error)
+	GetJSONContext(ctx context.Context, url string, v any) error
+	PostFormContext(ctx context.Context, url string, data url.Values, headers map[string]string) ([]byte, error)
+	PostJSONContext(ctx context.Context, url string, payload any, headers map[string]string) ([]byte, error)
 }
 
 // Client is a wrapper around http.Client
 type Client struct {
-	client *http.Client
+	client      *http.Client
+	limiter     *HostLimiter
+	retryPolicy RetryPolicy
+	retryBudget *retryBudget
+	cache       *Cache
 }
 
-// NewClient creates a new Client
-func NewClient() HTTPClient {
-	return &Client{
-		client: &http.Client{
-			Timeout: 15 * time.Second, // Increased timeout
-		},
+// Option configures a Client
+type Option func(*Client)
+
+// WithHostLimiter sets the limiter used to space out requests to the same host.
+// Passing nil disables rate limiting.
+func WithHostLimiter(limiter *HostLimiter) Option {
+	return func(c *Client) {
+		c.limiter = limiter
 	}
 }
 
-// Get performs a GET request to the specified URL
-func (c *Client) Get(url string) ([]byte, error) {
-	return c.GetWithHeaders(url, nil)
+// WithRetryPolicy sets the policy used to retry failed requests
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
 }
 
-// GetWithHeaders performs a GET request to the specified URL with custom headers
-func (c *Client) GetWithHeaders(url string, headers map[string]string) ([]byte, error) {
-	// Create request
-	req, err := http.NewRequest(http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// NewClient creates a new Client.
+// Unless overridden, requests are throttled by the limiter returned by DefaultHostLimiter
+// and retried according to DefaultRetryPolicy.
+func NewClient(opts ...Option) HTTPClient {
+	c := &Client{
+		client: &http.Client{
+			Timeout: 15 * time.Second, // Increased timeout
+		},
+		limiter:     defaultLimiter,
+		retryPolicy: DefaultRetryPolicy(),
 	}
 
-	// Set default headers
-	req.Header.Set("User-Agent", "GossipBot/1.0 (https://github.com/ducminhgd/gossip-bot; contact@example.com)")
-	req.Header.Set("Accept", "application/json")
-
-	// Set custom headers (will override defaults if same key)
-	for key, value := range headers {
-		req.Header.Set(key, value)
+	for _, opt := range opts {
+		opt(c)
 	}
+	c.retryBudget = newRetryBudget(c.retryPolicy.Budget)
 
-	// Perform request with retry logic
-	var resp *http.Response
-	var lastErr error
-	maxRetries := 3
-
-	for i := range maxRetries {
-		// Add a small delay between retries with some jitter
-		if i > 0 {
-			delay := time.Duration(500+rnd.Intn(500)) * time.Millisecond
-			time.Sleep(delay)
-		}
-
-		resp, err = c.client.Do(req)
-		if err != nil {
-			lastErr = fmt.Errorf("failed to perform request (attempt %d/%d): %w", i+1, maxRetries, err)
-			continue
-		}
-
-		// If we got a response, break out of the retry loop
-		break
-	}
+	return c
+}
 
-	// If all retries failed, return the last error
-	if resp == nil {
-		return nil, lastErr
-	}
+// Get performs a GET request to the specified URL
+func (c *Client) Get(url string) ([]byte, error) {
+	return c.GetContext(context.Background(), url)
+}
 
-	defer resp.Body.Close()
+// GetContext performs a GET request to the specified URL, bounded by ctx
+func (c *Client) GetContext(ctx context.Context, url string) ([]byte, error) {
+	return c.GetWithHeadersContext(ctx, url, nil)
+}
 
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
+// GetWithHeaders performs a GET request to the specified URL with custom headers
+func (c *Client) GetWithHeaders(url string, headers map[string]string) ([]byte, error) {
+	return c.GetWithHeadersContext(context.Background(), url, headers)
+}
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+// GetWithHeadersContext performs a GET request to the specified URL with custom headers, bounded by ctx
+func (c *Client) GetWithHeadersContext(ctx context.Context, url string, headers map[string]string) ([]byte, error) {
+	defaultHeaders := map[string]string{
+		"User-Agent": defaultUserAgent,
+		"Accept":     "application/json",
 	}
 
-	return body, nil
+	return c.do(ctx, http.MethodGet, url, nil, defaultHeaders, headers)
 }
 
 // GetJSON performs a GET request to the specified URL and unmarshals the response into v
 func (c *Client) GetJSON(url string, v any) error {
-	body, err := c.Get(url)
+	return c.GetJSONContext(context.Background(), url, v)
+}
+
+// GetJSONContext performs a GET request to the specified URL, bounded by ctx, and unmarshals the response into v
+func (c *Client) GetJSONContext(ctx context.Context, url string, v any) error {
+	body, err := c.GetContext(ctx, url)
 	if err != nil {
 		return err
 	}
@@ -118,60 +125,182 @@ func (c *Client) GetJSON(url string, v any) error {
 
 // PostForm performs a POST request with form data and optional basic authentication
 func (c *Client) PostForm(url string, data url.Values, headers map[string]string) ([]byte, error) {
-	// Create request with form data
-	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(data.Encode()))
+	return c.PostFormContext(context.Background(), url, data, headers)
+}
+
+// PostFormContext performs a POST request with form data and optional basic authentication, bounded by ctx
+func (c *Client) PostFormContext(ctx context.Context, url string, data url.Values, headers map[string]string) ([]byte, error) {
+	defaultHeaders := map[string]string{
+		"User-Agent":   defaultUserAgent,
+		"Content-Type": "application/x-www-form-urlencoded",
+	}
+
+	return c.do(ctx, http.MethodPost, url, []byte(data.Encode()), defaultHeaders, headers)
+}
+
+// PostJSON performs a POST request with a JSON-encoded payload, e.g. a GraphQL query
+func (c *Client) PostJSON(url string, payload any, headers map[string]string) ([]byte, error) {
+	return c.PostJSONContext(context.Background(), url, payload, headers)
+}
+
+// PostJSONContext performs a POST request with a JSON-encoded payload, bounded by ctx
+func (c *Client) PostJSONContext(ctx context.Context, url string, payload any, headers map[string]string) ([]byte, error) {
+	body, err := json.Marshal(payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	// Set default headers
-	req.Header.Set("User-Agent", "GossipBot/1.0 (https://github.com/ducminhgd/gossip-bot; contact@example.com)")
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	defaultHeaders := map[string]string{
+		"User-Agent":   defaultUserAgent,
+		"Accept":       "application/json",
+		"Content-Type": "application/json",
+	}
 
-	// Set custom headers (will override defaults if same key)
-	for key, value := range headers {
-		req.Header.Set(key, value)
+	return c.do(ctx, http.MethodPost, url, body, defaultHeaders, headers)
+}
+
+// do performs a request, retrying it according to the client's retry policy.
+// A new request is built for every attempt, so the body is replayed on retries.
+func (c *Client) do(ctx context.Context, method, url string, body []byte, defaultHeaders, headers map[string]string) ([]byte, error) {
+	maxAttempts := max(c.retryPolicy.MaxAttempts, 1)
+
+	// Revalidate cached GET responses instead of downloading them again
+	var cached *cacheEntry
+	if c.cache != nil && method == http.MethodGet {
+		cached, _ = c.cache.load(url)
 	}
 
-	// Perform request with retry logic
-	var resp *http.Response
 	var lastErr error
-	maxRetries := 3
+	for attempt := 1; ; attempt++ {
+		req, err := newRequest(ctx, method, url, body, defaultHeaders, headers)
+		if err != nil {
+			return nil, err
+		}
+		if cached != nil {
+			cached.setValidators(req)
+		}
 
-	for i := range maxRetries {
-		// Add a small delay between retries with some jitter
-		if i > 0 {
-			delay := time.Duration(500+rnd.Intn(500)) * time.Millisecond
-			time.Sleep(delay)
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx, req.URL.Host); err != nil {
+				return nil, err
+			}
 		}
 
-		resp, err = c.client.Do(req)
+		var retryAfter time.Duration
+		resp, err := c.client.Do(req)
 		if err != nil {
-			lastErr = fmt.Errorf("failed to perform request (attempt %d/%d): %w", i+1, maxRetries, err)
-			continue
+			lastErr = fmt.Errorf("failed to perform request (attempt %d/%d): %w", attempt, maxAttempts, err)
+			// Retrying is pointless once the context is done
+			if ctx.Err() != nil {
+				return nil, lastErr
+			}
+		} else {
+			respBody, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err == nil {
+				respBody, err = decodeBody(resp, respBody)
+			}
+
+			switch {
+			case err != nil:
+				lastErr = fmt.Errorf("failed to read response body: %w", err)
+			case resp.StatusCode == http.StatusOK:
+				if c.cache != nil && method == http.MethodGet {
+					if err := c.cache.store(url, resp.Header, respBody); err != nil {
+						fmt.Printf("WARNING: failed to cache response for %s: %v\n", url, err)
+					}
+				}
+				return respBody, nil
+			case resp.StatusCode == http.StatusNotModified && cached != nil:
+				return cached.Body, nil
+			default:
+				lastErr = newStatusError(req, resp, respBody)
+				if !c.retryPolicy.retryableStatus(resp.StatusCode) {
+					return nil, lastErr
+				}
+
+				var ok bool
+				if retryAfter, ok = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+					if c.retryPolicy.MaxDelay > 0 && retryAfter > c.retryPolicy.MaxDelay {
+						return nil, fmt.Errorf("%w (retry after %s exceeds the maximum delay)", lastErr, retryAfter)
+					}
+					// Hold back every request to this host, not only this one
+					if c.limiter != nil {
+						c.limiter.Pause(req.URL.Host, time.Now().Add(retryAfter))
+					}
+				}
+			}
+		}
+
+		if attempt >= maxAttempts {
+			return nil, lastErr
+		}
+		if !c.retryBudget.take() {
+			return nil, fmt.Errorf("retry budget exhausted: %w", lastErr)
+		}
+
+		// Wait before retrying, at least as long as the server asked us to
+		if err := sleep(ctx, max(c.retryPolicy.backoff(attempt), retryAfter)); err != nil {
+			return nil, err
 		}
+	}
+}
 
-		// If we got a response, break out of the retry loop
-		break
+// newRequest creates a request with the default headers, overridden by the custom headers
+func newRequest(ctx context.Context, method, url string, body []byte, defaultHeaders, headers map[string]string) (*http.Request, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
 	}
 
-	// If all retries failed, return the last error
-	if resp == nil {
-		return nil, lastErr
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	defer resp.Body.Close()
+	for key, value := range defaultHeaders {
+		req.Header.Set(key, value)
+	}
 
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	// Set custom headers (will override defaults if same key)
+	for key, value := range headers {
+		req.Header.Set(key, value)
 	}
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	return req, nil
+}
+
+// decodeBody decompresses a gzip response body that the transport left compressed.
+// The transport only decompresses transparently when it asked for gzip itself,
+// not when the request set its own Accept-Encoding header.
+func decodeBody(resp *http.Response, body []byte) ([]byte, error) {
+	if resp.Uncompressed || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") || len(body) == 0 {
+		return body, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress response body: %w", err)
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to decompress response body: %w", err)
 	}
 
-	return body, nil
+	return decoded, nil
+}
+
+// sleep pauses for d, returning early with the context error if ctx is done first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_DecompressesGzip(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, _ = writer.Write([]byte(`{"items": []}`))
+	writer.Close()
+
+	// The server always compresses, like the Stack Exchange API
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer server.Close()
+
+	client := newTestClient(testRetryPolicy())
+
+	testCases := []struct {
+		name    string
+		headers map[string]string
+	}{
+		{"transport negotiated", nil},
+		{"explicit Accept-Encoding", map[string]string{"Accept-Encoding": "gzip"}},
+	}
+
+	for _, tc := range testCases {
+		body, err := client.GetWithHeaders(server.URL, tc.headers)
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tc.name, err)
+			continue
+		}
+		if string(body) != `{"items": []}` {
+			t.Errorf("%s: expected the decompressed body, got %q", tc.name, body)
+		}
+	}
+}
+
+func TestClient_InvalidGzip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write([]byte("not gzip"))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(testRetryPolicy()).GetWithHeaders(server.URL, map[string]string{"Accept-Encoding": "gzip"})
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+}
+
+func TestClient_PostJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON POST, got %s with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		if r.Header.Get("Authorization") != "token" {
+			t.Errorf("Expected the custom header, got %q", r.Header.Get("Authorization"))
+		}
+
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		if payload["query"] != "{ me { id } }" {
+			t.Errorf("Unexpected payload: %v", payload)
+		}
+		_, _ = w.Write([]byte(`{"data": {}}`))
+	}))
+	defer server.Close()
+
+	payload := map[string]any{"query": "{ me { id } }"}
+	body, err := newTestClient(testRetryPolicy()).PostJSON(server.URL, payload, map[string]string{"Authorization": "token"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(body) != `{"data": {}}` {
+		t.Errorf("Unexpected body: %s", body)
+	}
+}
+
+func TestClient_PostJSONInvalidPayload(t *testing.T) {
+	_, err := newTestClient(testRetryPolicy()).PostJSON("http://example.com", make(chan int), nil)
+	if err == nil {
+		t.Fatal("Expected an error, got nil")
+	}
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxErrorBodySize is the maximum number of response body bytes kept in the excerpt of a StatusError
+	maxErrorBodySize = 512
+
+	// maxErrorRawBodySize is the maximum number of response body bytes kept as received in a StatusError
+	maxErrorRawBodySize = 64 << 10
+)
+
+// StatusError is returned when a request completes with an unexpected status code
+type StatusError struct {
+	// Method is the HTTP method of the request
+	Method string
+
+	// URL is the requested URL
+	URL string
+
+	// StatusCode is the response status code
+	StatusCode int
+
+	// Header holds the response headers
+	Header http.Header
+
+	// Body is the beginning of the response body on a single line, truncated to maxErrorBodySize bytes
+	Body string
+
+	// RawBody is the response body as received, cut at maxErrorRawBodySize bytes,
+	// for callers that parse the error document of an API
+	RawBody []byte
+}
+
+// newStatusError creates a StatusError from a response and its body
+func newStatusError(req *http.Request, resp *http.Response, body []byte) *StatusError {
+	return &StatusError{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       truncateBody(body),
+		RawBody:    body[:min(len(body), maxErrorRawBodySize)],
+	}
+}
+
+// Error implements the error interface
+func (e *StatusError) Error() string {
+	msg := fmt.Sprintf("unexpected status code: %d (%s %s)", e.StatusCode, e.Method, e.URL)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// IsRateLimited reports whether the upstream rejected the request because of rate limiting
+func (e *StatusError) IsRateLimited() bool {
+	if e.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	// GitHub and Reddit answer 403 with an exhausted rate limit header ("0" and "0.0" respectively)
+	if e.StatusCode != http.StatusForbidden {
+		return false
+	}
+	remaining, err := strconv.ParseFloat(e.Header.Get("X-RateLimit-Remaining"), 64)
+	return err == nil && remaining <= 0
+}
+
+// IsAuthError reports whether the upstream rejected the request's credentials or denied access
+func (e *StatusError) IsAuthError() bool {
+	return (e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden) && !e.IsRateLimited()
+}
+
+// IsNotFound reports whether the requested resource does not exist
+func (e *StatusError) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone
+}
+
+// AsStatusError returns the StatusError wrapped in err, if any
+func AsStatusError(err error) (*StatusError, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr, true
+	}
+	return nil, false
+}
+
+// truncateBody returns the body as a single-line string of at most maxErrorBodySize bytes,
+// cut on a rune boundary
+func truncateBody(body []byte) string {
+	truncated := len(body) > maxErrorBodySize
+	if truncated {
+		body = body[:maxErrorBodySize]
+		// Drop a rune split by the cut
+		for i := 0; i < utf8.UTFMax-1 && len(body) > 0; i++ {
+			if r, size := utf8.DecodeLastRune(body); r != utf8.RuneError || size > 1 {
+				break
+			}
+			body = body[:len(body)-1]
+		}
+	}
+
+	excerpt := strings.Join(strings.Fields(string(body)), " ")
+	if truncated {
+		excerpt += "..."
+	}
+	return excerpt
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestClient_ReturnsStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "abc")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("<html>\n  Blocked  </html>"))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(testRetryPolicy()).Get(server.URL + "/r/golang/hot.json")
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+
+	// The status error must survive wrapping
+	statusErr, ok := AsStatusError(fmt.Errorf("failed to fetch: %w", err))
+	if !ok {
+		t.Fatalf("Expected *StatusError, got %T", err)
+	}
+	if statusErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status code 403, got %d", statusErr.StatusCode)
+	}
+	if statusErr.URL != server.URL+"/r/golang/hot.json" {
+		t.Errorf("Expected URL %s, got %s", server.URL+"/r/golang/hot.json", statusErr.URL)
+	}
+	if statusErr.Header.Get("X-Request-Id") != "abc" {
+		t.Errorf("Expected response headers, got %v", statusErr.Header)
+	}
+	if statusErr.Body != "<html> Blocked </html>" {
+		t.Errorf("Expected collapsed body excerpt, got '%s'", statusErr.Body)
+	}
+	if string(statusErr.RawBody) != "<html>\n  Blocked  </html>" {
+		t.Errorf("Expected the raw body, got '%s'", statusErr.RawBody)
+	}
+	if !statusErr.IsAuthError() {
+		t.Error("Expected 403 to be an auth error")
+	}
+	if !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "Blocked") {
+		t.Errorf("Expected error message with status code and body, got '%s'", err.Error())
+	}
+}
+
+func TestStatusError_Classification(t *testing.T) {
+	testCases := []struct {
+		name        string
+		statusCode  int
+		header      http.Header
+		rateLimited bool
+		authError   bool
+		notFound    bool
+	}{
+		{"too many requests", http.StatusTooManyRequests, http.Header{}, true, false, false},
+		{"forbidden", http.StatusForbidden, http.Header{}, false, true, false},
+		{"forbidden rate limit", http.StatusForbidden, http.Header{"X-Ratelimit-Remaining": {"0.0"}}, true, false, false},
+		{"unauthorized", http.StatusUnauthorized, http.Header{}, false, true, false},
+		{"not found", http.StatusNotFound, http.Header{}, false, false, true},
+		{"gone", http.StatusGone, http.Header{}, false, false, true},
+		{"server error", http.StatusInternalServerError, http.Header{}, false, false, false},
+	}
+
+	for _, tc := range testCases {
+		err := &StatusError{StatusCode: tc.statusCode, Header: tc.header}
+		if err.IsRateLimited() != tc.rateLimited {
+			t.Errorf("%s: expected IsRateLimited %v", tc.name, tc.rateLimited)
+		}
+		if err.IsAuthError() != tc.authError {
+			t.Errorf("%s: expected IsAuthError %v", tc.name, tc.authError)
+		}
+		if err.IsNotFound() != tc.notFound {
+			t.Errorf("%s: expected IsNotFound %v", tc.name, tc.notFound)
+		}
+	}
+}
+
+func TestAsStatusError_OtherError(t *testing.T) {
+	if _, ok := AsStatusError(errors.New("network error")); ok {
+		t.Error("Expected no StatusError")
+	}
+}
+
+func TestTruncateBody(t *testing.T) {
+	body := []byte(strings.Repeat("a", maxErrorBodySize-1) + "é and more")
+	excerpt := truncateBody(body)
+
+	if !utf8.ValidString(excerpt) {
+		t.Errorf("Expected a valid UTF-8 excerpt, got %q", excerpt)
+	}
+	if excerpt != strings.Repeat("a", maxErrorBodySize-1)+"..." {
+		t.Errorf("Expected the split rune to be dropped, got %q", excerpt[maxErrorBodySize-8:])
+	}
+}
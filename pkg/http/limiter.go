@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultHostInterval is the minimum delay between two requests to the same host
+const DefaultHostInterval = 50 * time.Millisecond
+
+// defaultLimiter is shared by all clients created without their own limiter,
+// so every source talking to the same host is throttled together
+var defaultLimiter = NewHostLimiter(DefaultHostInterval)
+
+// HostLimiter spaces out requests to the same host so that we stay polite to upstreams
+type HostLimiter struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	intervals map[string]time.Duration
+	next      map[string]time.Time
+}
+
+// NewHostLimiter creates a HostLimiter that allows one request per interval to each host
+func NewHostLimiter(interval time.Duration) *HostLimiter {
+	return &HostLimiter{
+		interval:  interval,
+		intervals: make(map[string]time.Duration),
+		next:      make(map[string]time.Time),
+	}
+}
+
+// HostPauser is implemented by clients that can hold back their requests to a host.
+// Repositories use it when an API asks, in its response body, to slow down.
+type HostPauser interface {
+	PauseHost(host string, until time.Time)
+}
+
+// PauseHost holds back the client's requests to host until the given time.
+// It does nothing when rate limiting is disabled.
+func (c *Client) PauseHost(host string, until time.Time) {
+	if c.limiter != nil {
+		c.limiter.Pause(host, until)
+	}
+}
+
+// DefaultHostLimiter returns the limiter shared by clients created with NewClient
+func DefaultHostLimiter() *HostLimiter {
+	return defaultLimiter
+}
+
+// SetHostInterval overrides the minimum delay between two requests to host
+func (l *HostLimiter) SetHostInterval(host string, interval time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.intervals[host] = interval
+}
+
+// Pause holds back requests to host until the given time, e.g. when the host asked us to retry later
+func (l *HostLimiter) Pause(host string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until.After(l.next[host]) {
+		l.next[host] = until
+	}
+}
+
+// Wait blocks until a request to host is allowed, or returns the context error if ctx is done first
+func (l *HostLimiter) Wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+
+	interval, ok := l.intervals[host]
+	if !ok {
+		interval = l.interval
+	}
+	l.next[host] = slot.Add(interval)
+	l.mu.Unlock()
+
+	return sleep(ctx, time.Until(slot))
+}
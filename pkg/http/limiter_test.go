@@ -0,0 +1,86 @@
+package http
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHostLimiter_SpacesRequestsToSameHost(t *testing.T) {
+	limiter := NewHostLimiter(20 * time.Millisecond)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limiter.Wait(context.Background(), "example.com")
+		}()
+	}
+	wg.Wait()
+
+	// The first request goes through immediately, the next three wait one interval each
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("Expected requests to be spaced by the interval, took %s", elapsed)
+	}
+}
+
+func TestHostLimiter_HostsAreIndependent(t *testing.T) {
+	limiter := NewHostLimiter(time.Second)
+
+	start := time.Now()
+	limiter.Wait(context.Background(), "a.example.com")
+	limiter.Wait(context.Background(), "b.example.com")
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected different hosts not to wait for each other, took %s", elapsed)
+	}
+}
+
+func TestHostLimiter_SetHostInterval(t *testing.T) {
+	limiter := NewHostLimiter(time.Second)
+	limiter.SetHostInterval("fast.example.com", 0)
+
+	start := time.Now()
+	for range 3 {
+		limiter.Wait(context.Background(), "fast.example.com")
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the host interval override to apply, took %s", elapsed)
+	}
+}
+
+func TestHostLimiter_WaitCancelled(t *testing.T) {
+	limiter := NewHostLimiter(time.Hour)
+	_ = limiter.Wait(context.Background(), "example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx, "example.com"); err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestClient_PauseHost(t *testing.T) {
+	limiter := NewHostLimiter(0)
+	client := NewClient(WithHostLimiter(limiter))
+
+	pauser, ok := client.(HostPauser)
+	if !ok {
+		t.Fatal("Expected the client to implement HostPauser")
+	}
+	pauser.PauseHost("api.example.com", time.Now().Add(time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, "api.example.com"); err == nil {
+		t.Error("Expected requests to the paused host to be held back")
+	}
+	if err := limiter.Wait(context.Background(), "other.example.com"); err != nil {
+		t.Errorf("Expected other hosts not to be paused, got %v", err)
+	}
+}
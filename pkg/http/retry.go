@@ -0,0 +1,116 @@
+package http
+
+import (
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts per request, including the first one
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry, it doubles on every following retry
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay between two attempts.
+	// A Retry-After longer than MaxDelay is not waited for, the request fails instead.
+	MaxDelay time.Duration
+
+	// RetryStatusCodes are the response status codes worth retrying
+	RetryStatusCodes []int
+
+	// Budget is the maximum number of retries a client makes over its lifetime, 0 means unlimited.
+	// A client lives for one run, so this bounds how much a struggling upstream can slow a run down.
+	Budget int
+}
+
+// DefaultRetryPolicy returns the retry policy used by NewClient
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		RetryStatusCodes: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooEarly,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		Budget: 50,
+	}
+}
+
+// retryableStatus reports whether a response with the given status code should be retried
+func (p RetryPolicy) retryableStatus(statusCode int) bool {
+	return slices.Contains(p.RetryStatusCodes, statusCode)
+}
+
+// backoff returns the delay before the given retry (1 for the first retry),
+// using exponential backoff with equal jitter
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || delay < p.MaxDelay); i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryBudget counts the retries left for a client
+type retryBudget struct {
+	remaining atomic.Int64
+	unlimited bool
+}
+
+// newRetryBudget creates a retry budget allowing n retries, or unlimited retries if n <= 0
+func newRetryBudget(n int) *retryBudget {
+	b := &retryBudget{unlimited: n <= 0}
+	b.remaining.Store(int64(n))
+	return b
+}
+
+// take consumes one retry, returning false once the budget is exhausted.
+// A nil budget is unlimited.
+func (b *retryBudget) take() bool {
+	if b == nil || b.unlimited {
+		return true
+	}
+	return b.remaining.Add(-1) >= 0
+}
+
+// parseRetryAfter parses a Retry-After header, given either as delay-seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,223 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient creates a client without rate limiting and with short retry delays
+func newTestClient(policy RetryPolicy) *Client {
+	return NewClient(WithHostLimiter(nil), WithRetryPolicy(policy)).(*Client)
+}
+
+// testRetryPolicy is DefaultRetryPolicy with delays short enough for tests
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 2 * time.Second
+	return policy
+}
+
+func TestClient_RetriesRetryableStatus(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	body, err := newTestClient(testRetryPolicy()).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(body) != "ok" {
+		t.Errorf("Expected body 'ok', got '%s'", body)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+func TestClient_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(testRetryPolicy()).Get(server.URL)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	statusErr, ok := AsStatusError(err)
+	if !ok || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 status error, got '%v'", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 attempt, got %d", calls.Load())
+	}
+}
+
+func TestClient_HonoursRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	if _, err := newTestClient(testRetryPolicy()).Get(server.URL); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Expected to wait for Retry-After, took %s", elapsed)
+	}
+}
+
+func TestClient_RetryAfterBeyondMaxDelay(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(testRetryPolicy()).Get(server.URL)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Expected 1 attempt, got %d", calls.Load())
+	}
+}
+
+func TestClient_RetryBudget(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	policy := testRetryPolicy()
+	policy.Budget = 3
+	client := newTestClient(policy)
+
+	// Each request may retry twice, but the budget only allows three retries in total
+	for range 3 {
+		_, _ = client.Get(server.URL)
+	}
+
+	if calls.Load() != 6 {
+		t.Errorf("Expected 6 attempts, got %d", calls.Load())
+	}
+
+	_, err := client.Get(server.URL)
+	if err == nil || !strings.Contains(err.Error(), "retry budget exhausted") {
+		t.Errorf("Expected retry budget error, got %v", err)
+	}
+}
+
+func TestClient_PostFormReplaysBody(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "grant_type=client_credentials" {
+			t.Errorf("Expected form body on attempt %d, got '%s'", calls.Load()+1, body)
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("token"))
+	}))
+	defer server.Close()
+
+	data := url.Values{}
+	data.Set("grant_type", "client_credentials")
+
+	body, err := newTestClient(testRetryPolicy()).PostForm(server.URL, data, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(body) != "token" {
+		t.Errorf("Expected body 'token', got '%s'", body)
+	}
+}
+
+func TestClient_CancelledDuringBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	policy := testRetryPolicy()
+	policy.BaseDelay = time.Hour
+	policy.MaxDelay = 2 * time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := newTestClient(policy).GetContext(ctx, server.URL)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 7, 4, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		input    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{"0", 0, true},
+		{"Fri, 04 Jul 2025 00:00:30 GMT", 30 * time.Second, true},
+		{"Thu, 03 Jul 2025 00:00:00 GMT", 0, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tc := range testCases {
+		delay, ok := parseRetryAfter(tc.input, now)
+		if ok != tc.ok || delay != tc.expected {
+			t.Errorf("parseRetryAfter(%q) = %s, %v; expected %s, %v", tc.input, delay, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for retry, upper := range map[int]time.Duration{
+		1: 100 * time.Millisecond,
+		2: 200 * time.Millisecond,
+		3: 400 * time.Millisecond,
+		6: time.Second,
+	} {
+		delay := policy.backoff(retry)
+		if delay < upper/2 || delay > upper {
+			t.Errorf("Expected backoff for retry %d within [%s, %s], got %s", retry, upper/2, upper, delay)
+		}
+	}
+}